@@ -0,0 +1,347 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// accessTimeFormat is the Apache %t timestamp layout.
+const accessTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessEntry describes a single HTTP request written to an access log.
+type AccessEntry struct {
+	RemoteHost string
+	Ident      string
+	User       string
+	Time       time.Time
+	Method     string
+	Path       string
+	Proto      string
+	Status     int
+	Bytes      int64
+	Referer    string
+	UserAgent  string
+	Duration   time.Duration
+}
+
+// AccessLogger writes HTTP requests to a dedicated access log file in the
+// Apache combined log format:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://example.com/" "Mozilla/4.08"
+//
+// It is independent from the global logger, but its file is created with
+// the same permissions and rotated files are named the same way. Options
+// can select another format and rotate the file by size; see WithW3CFields
+// and WithAccessMaxSize.
+type AccessLogger struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+	format  func(e *AccessEntry) string
+	header  func(now time.Time) string
+}
+
+// AccessOption configures an AccessLogger.
+type AccessOption func(a *AccessLogger) error
+
+// WithAccessMaxSize rotates the access log before a write would make it
+// larger than n bytes. A single entry larger than n is still written to a
+// fresh file. Zero, the default, disables rotation by size; Rotate can
+// still be called.
+func WithAccessMaxSize(n int64) AccessOption {
+	return func(a *AccessLogger) error {
+		if n < 0 {
+			return fmt.Errorf("negative access log size %d", n)
+		}
+		a.maxSize = n
+		return nil
+	}
+}
+
+// NewAccessLogger opens filename for appending and returns an AccessLogger
+// writing to it.
+func NewAccessLogger(filename string, opts ...AccessOption) (*AccessLogger, error) {
+	a := &AccessLogger{path: filename, format: formatCombined}
+	for _, opt := range opts {
+		if err := opt(a); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.open(OpenAppend); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// open opens the access log file with mode and writes the header of the
+// format if the file is empty, so that appending to an existing file does
+// not repeat it. The caller must hold a.mu or own a exclusively.
+func (a *AccessLogger) open(mode OpenMode) error {
+	mu.Lock()
+	f, err := openLogFile(a.path, mode)
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	size := info.Size()
+	if a.header != nil && size == 0 {
+		n, err := f.WriteString(a.header(clockNow()))
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write access log header: %w", err)
+		}
+		size = int64(n)
+	}
+	a.file = f
+	a.size = size
+	return nil
+}
+
+// Rotate renames the access log file to a backup name with the current
+// time inserted before the extension, as Rotate does for the log file,
+// and starts a new file with the header of the format. It returns the
+// path of the backup.
+func (a *AccessLogger) Rotate() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return "", ErrClosed
+	}
+	return a.rotateLocked()
+}
+
+// rotateLocked rotates the file. The caller must hold a.mu.
+func (a *AccessLogger) rotateLocked() (string, error) {
+	backup := backupName(a.path, clockNow())
+	if err := os.Rename(a.path, backup); err != nil {
+		return "", fmt.Errorf("failed to rotate access log: %w", err)
+	}
+	a.file.Close()
+	a.file = nil
+	if err := a.open(OpenTruncate); err != nil {
+		return backup, fmt.Errorf("failed to reopen access log after rotation: %w", err)
+	}
+	return backup, nil
+}
+
+// LogAccess writes e to the access log, rotating it first if it would
+// grow beyond the size set with WithAccessMaxSize.
+//
+// Empty fields are written as "-", and quotes and control characters in
+// quoted fields are escaped the way Apache does.
+func (a *AccessLogger) LogAccess(e AccessEntry) {
+	line := a.format(&e)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return
+	}
+	if a.maxSize > 0 && a.size+int64(len(line)) > a.maxSize && a.size > a.headerSize() {
+		if _, err := a.rotateLocked(); err != nil {
+			reportError(err)
+			if a.file == nil {
+				return
+			}
+		}
+	}
+	n, err := a.file.WriteString(line)
+	a.size += int64(n)
+	if err != nil {
+		reportError(fmt.Errorf("failed to write access log entry: %w", err))
+	}
+}
+
+// headerSize returns the size of the header written to new files.
+func (a *AccessLogger) headerSize() int64 {
+	if a.header == nil {
+		return 0
+	}
+	return int64(len(a.header(clockNow())))
+}
+
+// Close closes the access log file. Calling Close more than once is safe.
+func (a *AccessLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// Handler returns middleware that writes an access log entry for every
+// request served by next.
+func (a *AccessLogger) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := clockNow()
+		next.ServeHTTP(rec, r)
+
+		a.LogAccess(AccessEntry{
+			RemoteHost: remoteHost(r),
+			User:       requestUser(r),
+			Time:       start,
+			Method:     r.Method,
+			Path:       r.RequestURI,
+			Proto:      r.Proto,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			Duration:   clockSince(start),
+		})
+	})
+}
+
+// formatCombined renders e as a combined log format line, including the
+// trailing newline.
+func formatCombined(e *AccessEntry) string {
+	var b strings.Builder
+	b.WriteString(accessField(e.RemoteHost))
+	b.WriteByte(' ')
+	b.WriteString(accessField(e.Ident))
+	b.WriteByte(' ')
+	b.WriteString(accessField(e.User))
+	b.WriteString(" [")
+	b.WriteString(e.Time.Format(accessTimeFormat))
+	b.WriteString("] \"")
+	b.WriteString(escapeAccess(e.Method))
+	b.WriteByte(' ')
+	b.WriteString(escapeAccess(e.Path))
+	b.WriteByte(' ')
+	b.WriteString(escapeAccess(e.Proto))
+	b.WriteString("\" ")
+	b.WriteString(strconv.Itoa(e.Status))
+	b.WriteByte(' ')
+	if e.Bytes > 0 {
+		b.WriteString(strconv.FormatInt(e.Bytes, 10))
+	} else {
+		b.WriteByte('-')
+	}
+	b.WriteString(" \"")
+	b.WriteString(quotedAccessField(e.Referer))
+	b.WriteString("\" \"")
+	b.WriteString(quotedAccessField(e.UserAgent))
+	b.WriteString("\"\n")
+	return b.String()
+}
+
+// accessField returns s escaped for an unquoted field, or "-" if empty.
+func accessField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(escapeAccess(s), " ", `\x20`)
+}
+
+// quotedAccessField returns s escaped for a quoted field, or "-" if empty.
+func quotedAccessField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return escapeAccess(s)
+}
+
+// escapeAccess escapes quotes, backslashes and non-printable bytes the way
+// Apache does in its access logs.
+func escapeAccess(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// remoteHost returns the client address of r without the port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestUser returns the authenticated user name of r, if any.
+func requestUser(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	if r.URL != nil && r.URL.User != nil {
+		return r.URL.User.Username()
+	}
+	return ""
+}
+
+// responseRecorder captures the status code and body size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and forwards it.
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the bytes written and forwards them.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, sending the buffered response to the
+// client if the underlying writer supports it.
+func (r *responseRecorder) Flush() {
+	r.wroteHeader = true
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker for handlers taking over the connection,
+// such as WebSocket upgrades. It fails with http.ErrNotSupported if the
+// underlying writer cannot be hijacked.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,186 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func countDirectives(t *testing.T, path string) int {
+	t.Helper()
+	n := 0
+	for _, line := range readLines(t, path) {
+		if strings.HasPrefix(line, "#Version:") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestW3CHeaderOnlyInNewFile(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	for i := 0; i < 2; i++ {
+		a, err := NewAccessLogger(path, WithW3CFields("cs-method", "sc-status"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.LogAccess(AccessEntry{Method: "GET", Status: 200})
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := countDirectives(t, path); n != 1 {
+		t.Errorf("header written %d times, want once", n)
+	}
+	if lines := readLines(t, path); len(lines) != 5 {
+		t.Errorf("got %d lines, want 3 directives and 2 entries: %q", len(lines), lines)
+	}
+}
+
+func TestW3CMissingValues(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	a, err := NewAccessLogger(path, WithW3CFields("cs-method", "cs-uri-query", "sc-status", "sc-bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.LogAccess(AccessEntry{Method: "HEAD", Path: "/", Status: 204})
+	a.LogAccess(AccessEntry{Method: "GET", Path: "/a?q=1", Status: 200, Bytes: 512})
+	a.LogAccess(AccessEntry{Method: "GET", Path: "/b"})
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readLines(t, path)[3:]
+	want := []string{"HEAD - 204 0", "GET q=1 200 512", "GET - - 0"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("entries = %q, want %q", lines, want)
+	}
+}
+
+func TestAccessLoggerRotate(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	a, err := NewAccessLogger(path, WithW3CFields("cs-method", "sc-status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.LogAccess(AccessEntry{Method: "GET", Status: 200})
+	clock.Advance(time.Second)
+	backup, err := a.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.LogAccess(AccessEntry{Method: "POST", Status: 201})
+
+	for _, p := range []string{backup, path} {
+		if n := countDirectives(t, p); n != 1 {
+			t.Errorf("%s has %d headers, want 1", filepath.Base(p), n)
+		}
+	}
+	if lines := readLines(t, path); !strings.HasSuffix(lines[len(lines)-1], "POST 201") {
+		t.Errorf("new file ends with %q, want the entry logged after rotation", lines[len(lines)-1])
+	}
+}
+
+func TestAccessLoggerMaxSize(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	a, err := NewAccessLogger(path, WithAccessMaxSize(300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	for i := 0; i < 10; i++ {
+		a.LogAccess(AccessEntry{RemoteHost: "10.0.0.1", Method: "GET", Path: "/", Proto: "HTTP/1.1", Status: 200, Time: clockNow()})
+		clock.Advance(time.Second)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("got %d files, want the log to have rotated", len(files))
+	}
+	total := 0
+	for _, f := range files {
+		p := filepath.Join(dir, f.Name())
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("%s is %d bytes, want at most 300", f.Name(), info.Size())
+		}
+		total += len(readLines(t, p))
+	}
+	if total != 10 {
+		t.Errorf("got %d entries across files, want 10", total)
+	}
+}
+
+func TestAccessHandlerFlushAndHijack(t *testing.T) {
+	a, err := NewAccessLogger(filepath.Join(t.TempDir(), "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	flushed := make(chan bool, 1)
+	h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hijack" {
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				t.Error("response writer does not implement http.Hijacker")
+				return
+			}
+			conn, buf, err := hj.Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+			buf.Flush()
+			conn.Close()
+			return
+		}
+		f, ok := w.(http.Flusher)
+		flushed <- ok
+		if ok {
+			f.Flush()
+		}
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for _, path := range []string{"/flush", "/hijack"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if path == "/hijack" && string(body) != "ok" {
+			t.Errorf("hijacked response body %q, want %q", body, "ok")
+		}
+	}
+	if !<-flushed {
+		t.Error("response writer does not implement http.Flusher")
+	}
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Attr is a key/value pair appended to the message of an entry by
+// InfoAttrs and friends. Values are stored without boxing them in an
+// interface, so building attributes does not allocate.
+type Attr struct {
+	Key string
+
+	kind attrKind
+	num  uint64
+	str  string
+	err  error
+}
+
+// attrKind identifies the type of the value of an Attr.
+type attrKind uint8
+
+const (
+	attrString attrKind = iota
+	attrInt
+	attrUint
+	attrFloat
+	attrBool
+	attrDuration
+	attrError
+)
+
+// Str returns an attribute with a string value.
+func Str(key, value string) Attr {
+	return Attr{Key: key, kind: attrString, str: value}
+}
+
+// Int returns an attribute with an integer value.
+func Int(key string, value int) Attr {
+	return Attr{Key: key, kind: attrInt, num: uint64(value)}
+}
+
+// Int64 returns an attribute with a 64-bit integer value.
+func Int64(key string, value int64) Attr {
+	return Attr{Key: key, kind: attrInt, num: uint64(value)}
+}
+
+// Uint64 returns an attribute with an unsigned integer value.
+func Uint64(key string, value uint64) Attr {
+	return Attr{Key: key, kind: attrUint, num: value}
+}
+
+// Float returns an attribute with a floating-point value.
+func Float(key string, value float64) Attr {
+	return Attr{Key: key, kind: attrFloat, num: math.Float64bits(value)}
+}
+
+// Bool returns an attribute with a boolean value.
+func Bool(key string, value bool) Attr {
+	a := Attr{Key: key, kind: attrBool}
+	if value {
+		a.num = 1
+	}
+	return a
+}
+
+// DurAttr returns an attribute with a duration value, rendered like
+// time.Duration.String. It is not named Dur because Dur already wraps a
+// duration for printf arguments.
+func DurAttr(key string, value time.Duration) Attr {
+	return Attr{Key: key, kind: attrDuration, num: uint64(value)}
+}
+
+// Err returns an attribute with the key "error" holding err.
+func Err(err error) Attr {
+	return Attr{Key: "error", kind: attrError, err: err}
+}
+
+// appendValue appends the value of a to b, rendered as fmt's %v would.
+func (a Attr) appendValue(b []byte) []byte {
+	switch a.kind {
+	case attrInt:
+		return strconv.AppendInt(b, int64(a.num), 10)
+	case attrUint:
+		return strconv.AppendUint(b, a.num, 10)
+	case attrFloat:
+		return strconv.AppendFloat(b, math.Float64frombits(a.num), 'g', -1, 64)
+	case attrBool:
+		return strconv.AppendBool(b, a.num != 0)
+	case attrDuration:
+		return append(b, time.Duration(a.num).String()...)
+	case attrError:
+		if a.err == nil {
+			return append(b, "<nil>"...)
+		}
+		return append(b, a.err.Error()...)
+	}
+	return append(b, a.str...)
+}
+
+// renderAttrs returns msg followed by the attributes as " key=value"
+// pairs in the given order.
+func renderAttrs(msg string, attrs []Attr) string {
+	if len(attrs) == 0 {
+		return msg
+	}
+	var buf [256]byte
+	b := append(buf[:0], msg...)
+	for _, a := range attrs {
+		b = append(b, ' ')
+		b = append(b, a.Key...)
+		b = append(b, '=')
+		b = a.appendValue(b)
+	}
+	return string(b)
+}
+
+// DebugAttrs logs msg with attributes at DEBUG level; see InfoAttrs.
+func DebugAttrs(msg string, attrs ...Attr) {
+	logAt(DEBUG, 1, func() string { return renderAttrs(msg, attrs) })
+}
+
+// InfoAttrs logs msg at INFO level with the attributes appended as
+// key=value pairs, in the order given:
+//
+//	logger.InfoAttrs("copied", logger.Int("files", n), logger.DurAttr("elapsed", d))
+//
+// Values are rendered exactly as the fields of InfoT.
+func InfoAttrs(msg string, attrs ...Attr) {
+	logAt(INFO, 1, func() string { return renderAttrs(msg, attrs) })
+}
+
+// WarnAttrs logs msg with attributes at WARN level; see InfoAttrs.
+func WarnAttrs(msg string, attrs ...Attr) {
+	logAt(WARN, 1, func() string { return renderAttrs(msg, attrs) })
+}
+
+// ErrorAttrs logs msg with attributes at ERROR level; see InfoAttrs.
+func ErrorAttrs(msg string, attrs ...Attr) {
+	logAt(ERROR, 1, func() string { return renderAttrs(msg, attrs) })
+}
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchConfig configures a Batcher.
+//
+// A batch is flushed as soon as it holds MaxCount entries, reaches MaxBytes
+// bytes as measured by Size, or its oldest entry has waited MaxWait.
+// Triggers left at zero are disabled, but at least one must be set.
+type BatchConfig struct {
+	MaxCount int
+	MaxBytes int
+	MaxWait  time.Duration
+
+	// Flush delivers a batch. It is called from a single goroutine, so
+	// batches are flushed one at a time and in order. The slice is owned
+	// by Flush once it is called.
+	Flush func([]Entry) error
+
+	// Size returns the number of bytes e contributes to a batch. If nil,
+	// the length of the file, function and message plus a fixed overhead
+	// for the remaining fields is used.
+	Size func(e *Entry) int
+
+	// Retry, if set, retries failed flushes. Retrying stops when Close
+	// gives up waiting.
+	Retry *RetryPolicy
+}
+
+// Batcher groups entries into batches for sinks that deliver many entries
+// per request. It is safe for use by multiple goroutines.
+//
+// Batches that fail to flush, after retries when BatchConfig.Retry is set,
+// are reported to the error handler and counted in Stats.BatchDropped.
+type Batcher struct {
+	cfg BatchConfig
+
+	// mu guards the fields below. cond is signaled when a batch is cut,
+	// taken by the flusher, or the batcher is closed.
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []Entry
+	size    int
+	timer   ClockTimer
+	gen     uint64
+	ready   [][]Entry
+	closed  bool
+
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var (
+	batchesSent    atomic.Uint64
+	batchedEntries atomic.Uint64
+	batchDropped   atomic.Uint64
+	batchFlushTime atomic.Int64
+)
+
+// NewBatcher returns a Batcher flushing according to c. It panics if
+// c.Flush is nil or no trigger is set.
+func NewBatcher(c BatchConfig) *Batcher {
+	if c.Flush == nil {
+		panic("logger: NewBatcher requires a Flush function")
+	}
+	if c.MaxCount <= 0 && c.MaxBytes <= 0 && c.MaxWait <= 0 {
+		panic("logger: NewBatcher requires MaxCount, MaxBytes or MaxWait")
+	}
+	if c.Size == nil {
+		c.Size = entrySize
+	}
+
+	b := &Batcher{
+		cfg:  c,
+		done: make(chan struct{}),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	go b.run()
+	return b
+}
+
+// entrySize estimates the encoded size of e.
+func entrySize(e *Entry) int {
+	return len(e.File) + len(e.Func) + len(e.Message) + 48
+}
+
+// Add appends e to the current batch, flushing it if that reaches a limit.
+// When a batch is cut while the previous one is still waiting to be
+// flushed, Add blocks until the flusher takes it, which slows producers
+// down rather than letting batches pile up. The wait does not hold up
+// other producers' Add calls or Close. After Close it returns ErrClosed.
+func (b *Batcher) Add(e Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	b.pending = append(b.pending, e)
+	b.size += b.cfg.Size(&e)
+
+	if (b.cfg.MaxCount > 0 && len(b.pending) >= b.cfg.MaxCount) ||
+		(b.cfg.MaxBytes > 0 && b.size >= b.cfg.MaxBytes) {
+		b.cutLocked()
+		for len(b.ready) > 1 && !b.closed {
+			b.cond.Wait()
+		}
+		return nil
+	}
+	if len(b.pending) == 1 && b.cfg.MaxWait > 0 {
+		gen := b.gen
+		b.timer = clockAfterFunc(b.cfg.MaxWait, func() { b.expire(gen) })
+	}
+	return nil
+}
+
+// cutLocked queues the current batch for the flusher. The caller must hold
+// b.mu.
+func (b *Batcher) cutLocked() {
+	if len(b.pending) > 0 {
+		b.ready = append(b.ready, b.pending)
+		b.cond.Broadcast()
+	}
+	b.pending = nil
+	b.size = 0
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
+
+// expire flushes the batch identified by gen once it has waited MaxWait,
+// unless it was flushed for another reason in the meantime.
+func (b *Batcher) expire(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed || b.gen != gen || len(b.pending) == 0 {
+		return
+	}
+	b.cutLocked()
+}
+
+// next waits for the next batch to flush. It returns false once the
+// batcher is closed and every batch has been taken.
+func (b *Batcher) next() ([]Entry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.ready) == 0 {
+		if b.closed {
+			return nil, false
+		}
+		b.cond.Wait()
+	}
+	batch := b.ready[0]
+	b.ready = b.ready[1:]
+	b.cond.Broadcast()
+	return batch, true
+}
+
+// run flushes batches until the batcher is closed.
+func (b *Batcher) run() {
+	defer close(b.done)
+	defer b.cancel()
+
+	for {
+		batch, ok := b.next()
+		if !ok {
+			return
+		}
+		start := clockNow()
+		err := b.flush(batch)
+		batchFlushTime.Add(int64(clockSince(start)))
+		if err != nil {
+			batchDropped.Add(uint64(len(batch)))
+			reportError(fmt.Errorf("logger: failed to flush batch of %d entries: %w", len(batch), err))
+			continue
+		}
+		batchesSent.Add(1)
+		batchedEntries.Add(uint64(len(batch)))
+	}
+}
+
+// flush delivers batch, retrying according to the configured policy.
+func (b *Batcher) flush(batch []Entry) error {
+	if b.cfg.Retry == nil {
+		return b.cfg.Flush(batch)
+	}
+	return b.cfg.Retry.Do(b.ctx, func() error {
+		return b.cfg.Flush(batch)
+	})
+}
+
+// Close flushes the pending batch and waits until every batch has been
+// flushed or ctx is done. Entries added after Close are rejected with
+// ErrClosed. If ctx ends first, Close returns its error, pending retries
+// are abandoned and the remaining batches are still flushed once in the
+// background.
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.cutLocked()
+		b.closed = true
+		b.cond.Broadcast()
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
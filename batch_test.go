@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcherFlushesInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var got []int
+	b := NewBatcher(BatchConfig{
+		MaxCount: 3,
+		Flush: func(batch []Entry) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, e := range batch {
+				got = append(got, e.Line)
+			}
+			return nil
+		},
+	})
+	for i := 0; i < 10; i++ {
+		if err := b.Add(Entry{Line: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add(Entry{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after Close = %v, want ErrClosed", err)
+	}
+
+	if len(got) != 10 {
+		t.Fatalf("flushed %v, want 10 entries", got)
+	}
+	for i, line := range got {
+		if line != i {
+			t.Fatalf("flushed %v, want entries in order", got)
+		}
+	}
+}
+
+func TestBatcherCloseWithStalledFlush(t *testing.T) {
+	release := make(chan struct{})
+	b := NewBatcher(BatchConfig{
+		MaxCount: 1,
+		Flush: func([]Entry) error {
+			<-release
+			return nil
+		},
+	})
+	defer func() {
+		close(release)
+		<-b.done
+	}()
+
+	// The first batch stalls in Flush, the second waits for the flusher
+	// and the third blocks its producer.
+	b.Add(Entry{})
+	b.Add(Entry{})
+	go b.Add(Entry{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan error)
+	go func() { closed <- b.Close(ctx) }()
+
+	select {
+	case err := <-closed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Close() = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close ignored its context while a producer was blocked")
+	}
+}
+
+func TestBatcherCountsOnlySuccessfulBatches(t *testing.T) {
+	before := CurrentStats()
+	fail := true
+	b := NewBatcher(BatchConfig{
+		MaxCount: 2,
+		Flush: func([]Entry) error {
+			if fail {
+				fail = false
+				return errors.New("unavailable")
+			}
+			return nil
+		},
+	})
+	SetErrorHandler(func(error) {})
+	defer SetErrorHandler(nil)
+
+	for i := 0; i < 4; i++ {
+		b.Add(Entry{})
+	}
+	b.Close(context.Background())
+
+	after := CurrentStats()
+	if n := after.Batches - before.Batches; n != 1 {
+		t.Errorf("Batches grew by %d, want 1", n)
+	}
+	if n := after.BatchedEntries - before.BatchedEntries; n != 2 {
+		t.Errorf("BatchedEntries grew by %d, want 2", n)
+	}
+	if n := after.BatchDropped - before.BatchDropped; n != 2 {
+		t.Errorf("BatchDropped grew by %d, want 2", n)
+	}
+}
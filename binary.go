@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Binary record layout.
+//
+// Every record is framed as a uvarint body length, the body, and the
+// CRC-32 (IEEE) of the body as 4 big-endian bytes. The body starts with a
+// fixed header:
+//
+//	seq        uint64, big-endian
+//	level      int8
+//	unix nanos int64, big-endian
+//	pid        uint32, big-endian
+//
+// followed by the line as a uvarint and the file, function and message as
+// uvarint length-prefixed strings, and finally a uvarint count of
+// key/value string pairs. The only pair written is "event_id" for entries
+// with an ID; unknown pairs are skipped when decoding.
+const (
+	binaryHeaderSize = 8 + 1 + 8 + 4
+	maxBinaryRecord  = 16 << 20
+)
+
+var (
+	// ErrTruncated is returned by Decoder.Err when the input ends in the
+	// middle of a record, typically because the writer crashed.
+	ErrTruncated = errors.New("logger: truncated binary record")
+
+	// ErrCorrupt is returned by Decoder.Err when a record length is
+	// implausible, so the stream cannot be resynchronized.
+	ErrCorrupt = errors.New("logger: corrupt binary record")
+
+	// binarySeq is the sequence number of the last binary record written.
+	binarySeq uint64
+)
+
+// WithBinaryFormat writes entries in a compact length-prefixed binary
+// encoding instead of text. It avoids formatting timestamps and messages
+// on the hot path; use Decoder or ConvertBinary to read such files. The
+// configured formatter is ignored in this mode.
+func WithBinaryFormat() Option {
+	return func(c *config) {
+		c.binary = true
+	}
+}
+
+// appendBinaryRecord appends the framed binary encoding of e to buf.
+func appendBinaryRecord(buf []byte, seq uint64, e *Entry) []byte {
+	var body [binaryHeaderSize]byte
+	binary.BigEndian.PutUint64(body[0:8], seq)
+	body[8] = byte(int8(e.Level))
+	binary.BigEndian.PutUint64(body[9:17], uint64(e.Time.UnixNano()))
+	binary.BigEndian.PutUint32(body[17:21], uint32(e.PID))
+
+	payload := make([]byte, 0, binaryHeaderSize+len(e.File)+len(e.Func)+len(e.Message)+16)
+	payload = append(payload, body[:]...)
+	payload = binary.AppendUvarint(payload, uint64(e.Line))
+	payload = appendBinaryString(payload, e.File)
+	payload = appendBinaryString(payload, e.Func)
+	payload = appendBinaryString(payload, e.Message)
+	if e.ID != "" {
+		payload = binary.AppendUvarint(payload, 1)
+		payload = appendBinaryString(payload, "event_id")
+		payload = appendBinaryString(payload, e.ID)
+	} else {
+		payload = binary.AppendUvarint(payload, 0)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(payload)))
+	buf = append(buf, payload...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(payload))
+}
+
+// appendBinaryString appends s prefixed with its length.
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// Decoder reads entries from a stream written with WithBinaryFormat.
+//
+// Records whose checksum does not match are skipped and counted. A record
+// cut short at the end of the input stops decoding, and Err then reports
+// ErrTruncated; an implausible record length stops it with ErrCorrupt.
+type Decoder struct {
+	r       *bufio.Reader
+	entry   Entry
+	seq     uint64
+	skipped int
+	err     error
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Next advances to the next valid record, returning false at the end of
+// the input or on error.
+func (d *Decoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	for {
+		size, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			if err != io.EOF {
+				d.err = ErrTruncated
+			}
+			return false
+		}
+		if size > maxBinaryRecord {
+			d.err = ErrCorrupt
+			return false
+		}
+
+		record := make([]byte, size+4)
+		if _, err := io.ReadFull(d.r, record); err != nil {
+			d.err = ErrTruncated
+			return false
+		}
+		payload, sum := record[:size], record[size:]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(sum) {
+			d.skipped++
+			continue
+		}
+
+		if err := d.decode(payload); err != nil {
+			d.skipped++
+			continue
+		}
+		return true
+	}
+}
+
+// decode parses a record body into the decoder's current entry.
+func (d *Decoder) decode(p []byte) error {
+	if len(p) < binaryHeaderSize {
+		return errors.New("short record")
+	}
+	d.seq = binary.BigEndian.Uint64(p[0:8])
+	e := Entry{
+		Level: LogLevel(int8(p[8])),
+		Time:  time.Unix(0, int64(binary.BigEndian.Uint64(p[9:17]))),
+		PID:   int(binary.BigEndian.Uint32(p[17:21])),
+	}
+	p = p[binaryHeaderSize:]
+
+	line, n := binary.Uvarint(p)
+	if n <= 0 {
+		return errors.New("bad line")
+	}
+	e.Line = int(line)
+	p = p[n:]
+
+	var err error
+	if e.File, p, err = readBinaryString(p); err != nil {
+		return err
+	}
+	if e.Func, p, err = readBinaryString(p); err != nil {
+		return err
+	}
+	if e.Message, p, err = readBinaryString(p); err != nil {
+		return err
+	}
+
+	pairs, n := binary.Uvarint(p)
+	if n <= 0 {
+		return errors.New("bad field count")
+	}
+	p = p[n:]
+	for i := uint64(0); i < pairs; i++ {
+		var key, value string
+		if key, p, err = readBinaryString(p); err != nil {
+			return err
+		}
+		if value, p, err = readBinaryString(p); err != nil {
+			return err
+		}
+		if key == "event_id" {
+			e.ID = value
+		}
+	}
+
+	d.entry = e
+	return nil
+}
+
+// readBinaryString reads a length-prefixed string from p and returns the
+// rest of p.
+func readBinaryString(p []byte) (string, []byte, error) {
+	size, n := binary.Uvarint(p)
+	if n <= 0 || uint64(len(p)-n) < size {
+		return "", nil, errors.New("bad string")
+	}
+	p = p[n:]
+	return string(p[:size]), p[size:], nil
+}
+
+// Entry returns the entry decoded by the last successful call to Next.
+func (d *Decoder) Entry() Entry {
+	return d.entry
+}
+
+// Seq returns the sequence number of the entry returned by Entry.
+func (d *Decoder) Seq() uint64 {
+	return d.seq
+}
+
+// Skipped returns the number of corrupted records skipped so far.
+func (d *Decoder) Skipped() int {
+	return d.skipped
+}
+
+// Err returns ErrTruncated or ErrCorrupt if decoding stopped before the
+// end of the input, and nil otherwise.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// ConvertBinary decodes the binary log read from src and writes it to dst
+// in the default text format. A truncated final record is ignored.
+func ConvertBinary(dst io.Writer, src io.Reader) error {
+	w := bufio.NewWriter(dst)
+	d := NewDecoder(src)
+	var text TextFormatter
+	for d.Next() {
+		e := d.Entry()
+		if _, err := fmt.Fprintln(w, text.Format(&e)); err != nil {
+			return err
+		}
+	}
+	if err := d.Err(); err != nil && err != ErrTruncated {
+		return err
+	}
+	return w.Flush()
+}
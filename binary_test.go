@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	now := time.Date(2026, 5, 2, 8, 30, 0, 123456789, time.Local)
+	useFakeClock(t, now)
+	dir := initTestLogger(t, "app.bin", WithBinaryFormat(), WithEventIDs())
+
+	msgs := []string{"first", "multi\nline message", strings.Repeat("long ", 100), ""}
+	for _, msg := range msgs {
+		Warn("%s", msg)
+	}
+	Close()
+
+	f, err := os.Open(filepath.Join(dir, "app.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	d := NewDecoder(f)
+	var prev uint64
+	i := 0
+	for ; d.Next(); i++ {
+		e := d.Entry()
+		if i >= len(msgs) {
+			t.Fatalf("decoded extra entry %+v", e)
+		}
+		if e.Message != msgs[i] || e.Level != WARN || !e.Time.Equal(now) || e.PID != os.Getpid() {
+			t.Errorf("entry %d = %+v", i, e)
+		}
+		if e.File != "binary_test.go" || e.Func != "TestBinaryRoundTrip" || e.Line == 0 {
+			t.Errorf("entry %d attributed to %s:%d %s", i, e.File, e.Line, e.Func)
+		}
+		if !isEventID(e.ID) {
+			t.Errorf("entry %d has ID %q", i, e.ID)
+		}
+		if d.Seq() <= prev {
+			t.Errorf("entry %d has sequence %d after %d", i, d.Seq(), prev)
+		}
+		prev = d.Seq()
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != len(msgs) || d.Skipped() != 0 {
+		t.Errorf("decoded %d entries and skipped %d, want %d and 0", i, d.Skipped(), len(msgs))
+	}
+}
+
+// binaryStream encodes an entry for each message.
+func binaryStream(msgs ...string) []byte {
+	var buf []byte
+	for i, msg := range msgs {
+		buf = appendBinaryRecord(buf, uint64(i+1), &Entry{Level: INFO, Time: time.Unix(0, 0), Message: msg})
+	}
+	return buf
+}
+
+func TestBinaryTruncatedRecord(t *testing.T) {
+	data := binaryStream("one", "two", "three")
+	for cut := len(binaryStream("one", "two")) + 1; cut < len(data); cut++ {
+		d := NewDecoder(bytes.NewReader(data[:cut]))
+		var got []string
+		for d.Next() {
+			got = append(got, d.Entry().Message)
+		}
+		if strings.Join(got, ",") != "one,two" || d.Err() != ErrTruncated {
+			t.Fatalf("cut at %d: decoded %q, %v; want one,two and ErrTruncated", cut, got, d.Err())
+		}
+	}
+
+	var text bytes.Buffer
+	if err := ConvertBinary(&text, bytes.NewReader(data[:len(data)-1])); err != nil {
+		t.Fatalf("ConvertBinary of a truncated log: %v", err)
+	}
+	if n := strings.Count(text.String(), "\n"); n != 2 {
+		t.Errorf("converted %d lines, want 2:\n%s", n, text.String())
+	}
+}
+
+func TestBinaryCorruptRecordSkipped(t *testing.T) {
+	data := binaryStream("one", "two", "three")
+	data[len(binaryStream("one"))+binaryHeaderSize+5] ^= 0xff
+
+	d := NewDecoder(bytes.NewReader(data))
+	var got []string
+	for d.Next() {
+		got = append(got, d.Entry().Message)
+	}
+	if strings.Join(got, ",") != "one,three" || d.Skipped() != 1 || d.Err() != nil {
+		t.Errorf("decoded %q, skipped %d, err %v; want one,three, 1 and nil", got, d.Skipped(), d.Err())
+	}
+
+	huge := append([]byte{0xff, 0xff, 0xff, 0xff, 0x7f}, data...)
+	d = NewDecoder(bytes.NewReader(huge))
+	if d.Next() || d.Err() != ErrCorrupt {
+		t.Errorf("implausible length: err %v, want ErrCorrupt", d.Err())
+	}
+}
+
+func BenchmarkBinaryInfo(b *testing.B) {
+	initTestLogger(b, "bench.bin", WithBinaryFormat())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info("request %d handled", i)
+	}
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// BuildInfo identifies the build of the running program.
+type BuildInfo struct {
+	// Path is the main module path, Version its version as recorded by
+	// the go command or set with SetVersion.
+	Path    string
+	Version string
+
+	// Revision, Time and Modified describe the VCS state the binary was
+	// built from, when the go command recorded it.
+	Revision string
+	Time     time.Time
+	Modified bool
+}
+
+var (
+	buildInfo       atomic.Pointer[BuildInfo]
+	versionOverride atomic.Pointer[string]
+)
+
+// EnableBuildInfo reads the build information embedded in the binary and
+// makes it available to CurrentBuildInfo, Banner and the ver= token of
+// TextFormatter. Binaries built without module support or VCS stamping
+// simply report less; EnableBuildInfo never fails.
+func EnableBuildInfo() {
+	var bi BuildInfo
+	if info, ok := debug.ReadBuildInfo(); ok {
+		bi.Path = info.Main.Path
+		if v := info.Main.Version; v != "(devel)" {
+			bi.Version = v
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				bi.Revision = s.Value
+			case "vcs.time":
+				bi.Time, _ = time.Parse(time.RFC3339, s.Value)
+			case "vcs.modified":
+				bi.Modified = s.Value == "true"
+			}
+		}
+	}
+	buildInfo.Store(&bi)
+}
+
+// SetVersion overrides the version reported for the program, for binaries
+// that receive it through -ldflags "-X ...". An empty version removes the
+// override.
+func SetVersion(version string) {
+	if version == "" {
+		versionOverride.Store(nil)
+		return
+	}
+	versionOverride.Store(&version)
+}
+
+// CurrentBuildInfo returns the build information collected by
+// EnableBuildInfo, with the version set by SetVersion if any.
+func CurrentBuildInfo() BuildInfo {
+	var bi BuildInfo
+	if p := buildInfo.Load(); p != nil {
+		bi = *p
+	}
+	if v := versionOverride.Load(); v != nil {
+		bi.Version = *v
+	}
+	return bi
+}
+
+// versionToken returns the compact build identifier used in the ver=
+// token: the version if known, otherwise the abbreviated revision, marked
+// "-dirty" for modified trees. It is empty when nothing is known.
+func versionToken() string {
+	bi := CurrentBuildInfo()
+	if bi.Version != "" {
+		return bi.Version
+	}
+	if bi.Revision == "" {
+		return ""
+	}
+	rev := bi.Revision
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if bi.Modified {
+		rev += "-dirty"
+	}
+	return rev
+}
+
+// Banner logs a single INFO entry identifying the running program: its
+// module path and build, the Go runtime, the process ID and the host name.
+// It is meant to be called once at startup, after EnableBuildInfo.
+func Banner() {
+	if !enabled(INFO, 1) {
+		return
+	}
+
+	bi := CurrentBuildInfo()
+	var b strings.Builder
+	b.WriteString("starting")
+	if bi.Path != "" {
+		b.WriteString(" " + bi.Path)
+	}
+	if bi.Version != "" {
+		b.WriteString(" " + bi.Version)
+	}
+	if bi.Revision != "" {
+		fmt.Fprintf(&b, " (revision %s", bi.Revision)
+		if !bi.Time.IsZero() {
+			fmt.Fprintf(&b, " from %s", bi.Time.Format(time.RFC3339))
+		}
+		if bi.Modified {
+			b.WriteString(", modified")
+		}
+		b.WriteString(")")
+	}
+	host, _ := os.Hostname()
+	fmt.Fprintf(&b, ", %s %s/%s, pid %d, host %s", runtime.Version(), runtime.GOOS, runtime.GOARCH, os.Getpid(), host)
+
+	write(newEntry(clockNow(), INFO, 1, b.String()))
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCallerDepth is the number of frames examined when looking for the
+// first frame outside of the logger and registered wrapper packages.
+const maxCallerDepth = 32
+
+var (
+	skipPrefixesMu sync.Mutex
+	skipPrefixes   atomic.Pointer[[]string]
+
+	// selfPackage is the import path of this package.
+	selfPackage = currentPackage()
+)
+
+// currentPackage returns the import path of the package defining it.
+func currentPackage() string {
+	pc, _, _, _ := runtime.Caller(0)
+	return packagePath(runtime.FuncForPC(pc).Name())
+}
+
+// RegisterCallerSkipPrefix marks the packages below the import path prefix
+// as logging wrappers, such as "github.com/acme/common/logwrap".
+//
+// Once a prefix is registered, entries are attributed to the first frame
+// on the stack outside of this package and the wrapper packages, however
+// deeply the wrappers nest, instead of a fixed number of frames above the
+// logging call. Module levels are matched against the same frame. If no
+// such frame is found within 32 frames, the usual caller is used.
+func RegisterCallerSkipPrefix(prefix string) {
+	skipPrefixesMu.Lock()
+	defer skipPrefixesMu.Unlock()
+
+	var prefixes []string
+	if p := skipPrefixes.Load(); p != nil {
+		prefixes = append(prefixes, *p...)
+	}
+	for _, p := range prefixes {
+		if p == prefix {
+			return
+		}
+	}
+	prefixes = append(prefixes, prefix)
+	skipPrefixes.Store(&prefixes)
+}
+
+// callerFrame returns the first frame, starting skip frames above the
+// caller of callerFrame, that is outside of this package and the
+// registered wrapper packages. It reports false when no prefix is
+// registered or no such frame is found.
+func callerFrame(skip int) (runtime.Frame, bool) {
+	p := skipPrefixes.Load()
+	if p == nil {
+		return runtime.Frame{}, false
+	}
+
+	var pcs [maxCallerDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !isWrapperPackage(packagePath(f.Function), *p) {
+			return f, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// isWrapperPackage reports whether the frames of pkg are skipped when
+// resolving the caller.
+func isWrapperPackage(pkg string, prefixes []string) bool {
+	if pkg == selfPackage {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/73ddy-io/logger"
+	"github.com/73ddy-io/logger/testdata/wrap/outer"
+)
+
+func TestCallerSkipsNestedWrappers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := logger.InitLogger(path); err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	logger.RegisterCallerSkipPrefix("github.com/73ddy-io/logger/testdata/wrap")
+	defer logger.ResetCallerSkipPrefixes()
+
+	outer.Info("through two wrappers")
+
+	logger.SetModuleLevel("github.com/73ddy-io/logger_test", logger.WARN)
+	defer logger.ClearModuleLevel("github.com/73ddy-io/logger_test")
+	outer.Info("filtered by the level of the caller")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(lines), lines)
+	}
+	e, err := logger.ParseLine(lines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.File != "caller_test.go" || e.Func != "TestCallerSkipsNestedWrappers" {
+		t.Errorf("entry attributed to %s:%d %s, want the caller of outer.Info", e.File, e.Line, e.Func)
+	}
+}
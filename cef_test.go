@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCEFEscaping(t *testing.T) {
+	f := CEFFormatter{Vendor: "Acme|Corp", Product: `log\ger`, Version: "1.0"}
+	tests := []struct {
+		name  string
+		entry Entry
+		want  string
+	}{
+		{
+			name:  "plain",
+			entry: Entry{Level: INFO, Message: "started", File: "main.go", Line: 7, Func: "main"},
+			want:  `CEF:0|Acme\|Corp|log\\ger|1.0|INFO|started|3|rt=1700000000000 dvcpid=42 fname=main.go cn1=7 cn1Label=line cs1=main cs1Label=function`,
+		},
+		{
+			name:  "header escapes backslash and pipe but not equals",
+			entry: Entry{Level: WARN, Message: `a\b|c=d`, File: "f.go", Func: "f"},
+			want:  `CEF:0|Acme\|Corp|log\\ger|1.0|WARN|a\\b\|c=d|6|rt=1700000000000 dvcpid=42 fname=f.go cn1=0 cn1Label=line cs1=f cs1Label=function`,
+		},
+		{
+			name:  "header replaces line breaks",
+			entry: Entry{Level: ERROR, Message: "one\ntwo\r\nthree\rfour", File: "f.go", Func: "f"},
+			want:  `CEF:0|Acme\|Corp|log\\ger|1.0|ERR|one two three four|9|rt=1700000000000 dvcpid=42 fname=f.go cn1=0 cn1Label=line cs1=f cs1Label=function`,
+		},
+		{
+			name:  "extension escapes backslash, equals and line breaks but not pipe",
+			entry: Entry{Level: DEBUG, Message: "m", File: `dir\a=b|c.go`, Func: "x\ny\r\nz\rw"},
+			want:  `CEF:0|Acme\|Corp|log\\ger|1.0|DEBUG|m|1|rt=1700000000000 dvcpid=42 fname=dir\\a\=b|c.go cn1=0 cn1Label=line cs1=x\ny\nz\rw cs1Label=function`,
+		},
+		{
+			name:  "entry ID",
+			entry: Entry{Level: INFO, Message: "m", File: "f.go", Func: "f", ID: "01HV4Q9Z5W3X8Y7Z6A5B4C3D2E"},
+			want:  `CEF:0|Acme\|Corp|log\\ger|1.0|INFO|m|3|rt=1700000000000 dvcpid=42 fname=f.go cn1=0 cn1Label=line cs1=f cs1Label=function externalId=01HV4Q9Z5W3X8Y7Z6A5B4C3D2E`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			e.Time = time.UnixMilli(1700000000000)
+			e.PID = 42
+			if got := f.Format(&e); got != tt.want {
+				t.Errorf("Format =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time used by the logger: entry timestamps,
+// durations, rotation and date layouts, batching, retries, alerts and
+// polling. Tests can replace it with SetClock to control time instead of
+// sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) ClockTimer
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is a timer created by a Clock. C returns nil for timers
+// created with AfterFunc.
+type ClockTimer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// clockBox holds a Clock so that it can be stored atomically.
+type clockBox struct {
+	Clock
+}
+
+var clock atomic.Pointer[clockBox]
+
+func init() {
+	clock.Store(&clockBox{realClock{}})
+}
+
+// SetClock makes c the clock of the logger. Passing nil restores the
+// system clock. Timers that are already running keep their clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	clock.Store(&clockBox{c})
+}
+
+// CurrentClock returns the clock of the logger, the system clock unless
+// SetClock was called. Packages built on the logger, such as fluent and
+// s3archive, take their time from it so that SetClock controls them too.
+func CurrentClock() Clock {
+	return clock.Load().Clock
+}
+
+// clockNow returns the current time of the logger's clock.
+func clockNow() time.Time {
+	return clock.Load().Now()
+}
+
+// clockSince returns the time elapsed since t on the logger's clock.
+func clockSince(t time.Time) time.Duration {
+	return clockNow().Sub(t)
+}
+
+// newClockTimer returns a timer of the logger's clock firing after d.
+func newClockTimer(d time.Duration) ClockTimer {
+	return clock.Load().NewTimer(d)
+}
+
+// clockAfterFunc calls f after d on the logger's clock.
+func clockAfterFunc(d time.Duration, f func()) ClockTimer {
+	return clock.Load().AfterFunc(d, f)
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) ClockTimer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// realTimer adapts a time.Timer to ClockTimer.
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time {
+	return r.t.C
+}
+
+func (r realTimer) Stop() bool {
+	return r.t.Stop()
+}
@@ -0,0 +1,186 @@
+package logger
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a timer of a fakeClock.
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	ch    chan time.Time
+	f     func()
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) ClockTimer {
+	return c.add(d, nil)
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return c.add(d, f)
+}
+
+func (c *fakeClock) add(d time.Duration, f func()) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	if f == nil {
+		t.ch = make(chan time.Time, 1)
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and fires the timers that became
+// due, in order. AfterFunc callbacks run synchronously.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due, pending []*fakeTimer
+	for _, t := range c.timers {
+		if t.at.After(now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, t := range due {
+		if t.f != nil {
+			t.f()
+		} else {
+			t.ch <- now
+		}
+	}
+}
+
+// Waiters returns the number of timers that have not fired or been
+// stopped.
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// useFakeClock installs a fake clock set to now for the duration of the
+// test.
+func useFakeClock(t interface{ Cleanup(func()) }, now time.Time) *fakeClock {
+	c := newFakeClock(now)
+	SetClock(c)
+	t.Cleanup(func() { SetClock(nil) })
+	return c
+}
+
+// wallClockAllowed lists the files that may read the system clock
+// directly: the real Clock itself and the lock file fallback, whose lock
+// ages come from the file system.
+var wallClockAllowed = map[string]bool{
+	"clock.go":     true,
+	"lock_file.go": true,
+}
+
+var wallClockCall = regexp.MustCompile(`\btime\.(Now|Since|Until|After|AfterFunc|NewTimer|NewTicker|Tick|Sleep)\(`)
+
+// TestNoDirectWallClock makes sure that the logger and the packages built
+// on it take their time from the Clock, so that SetClock controls them.
+func TestNoDirectWallClock(t *testing.T) {
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || wallClockAllowed[path] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if wallClockCall.MatchString(line) {
+				t.Errorf("%s:%d reads the system clock; use the logger's Clock: %s", path, i+1, strings.TrimSpace(line))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEntriesUseClock(t *testing.T) {
+	now := time.Date(2026, 7, 4, 9, 30, 0, 0, time.Local)
+	clock := useFakeClock(t, now)
+	dir := initTestLogger(t, "app.log")
+
+	Info("first")
+	clock.Advance(90 * time.Minute)
+	Info("second")
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	want := []time.Time{now, now.Add(90 * time.Minute)}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), lines)
+	}
+	for i, line := range lines {
+		e, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+		if !e.Time.Equal(want[i]) {
+			t.Errorf("entry %d logged at %v, want %v", i, e.Time, want[i])
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ConsoleFormatter renders entries as aligned columns for reading in a
+// terminal, such as with a CallerWidth of 16:
+//
+//	2006-01-02 15:04:05 INFO        main.go:12 message
+//	2006-01-02 15:04:05 WARN  a_very_…e.go:140 message
+//
+// The level is padded to a fixed width and the caller, file and line, is
+// right-aligned in a column of CallerWidth cells, with long file names
+// shortened in the middle, so that messages always start in the same
+// column. Widths are counted in terminal cells, so wide characters such as
+// CJK ideographs count twice.
+//
+// ConsoleFormatter is meant for terminals; the default file format stays
+// TextFormatter.
+type ConsoleFormatter struct {
+	// CallerWidth is the width of the caller column. It defaults to 24.
+	CallerWidth int
+
+	// Color highlights the level with ANSI colors and dims the entry ID.
+	Color bool
+}
+
+// levelWidth is the width of the level column, fitting "DEBUG".
+const levelWidth = 5
+
+// Format implements Formatter.
+func (f ConsoleFormatter) Format(e *Entry) string {
+	width := f.CallerWidth
+	if width <= 0 {
+		width = 24
+	}
+
+	var b strings.Builder
+	b.WriteString(formatTimestamp(e.Time))
+	b.WriteByte(' ')
+
+	level := levelName(e.Level)
+	if f.Color {
+		b.WriteString(consoleLevelColor(e.Level))
+		b.WriteString(level)
+		b.WriteString("\x1b[0m")
+	} else {
+		b.WriteString(level)
+	}
+	b.WriteString(strings.Repeat(" ", levelWidth-len(level)+1))
+
+	caller := truncateMiddle(e.File+":"+strconv.Itoa(e.Line), width)
+	b.WriteString(strings.Repeat(" ", width-displayWidth(caller)))
+	b.WriteString(caller)
+	b.WriteByte(' ')
+
+	b.WriteString(e.Message)
+
+	if e.ID != "" {
+		b.WriteByte(' ')
+		if f.Color {
+			b.WriteString("\x1b[2mid=" + e.ID + "\x1b[0m")
+		} else {
+			b.WriteString("id=" + e.ID)
+		}
+	}
+	return b.String()
+}
+
+// consoleLevelColor returns the ANSI escape sequence coloring level.
+func consoleLevelColor(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "\x1b[90m"
+	case INFO:
+		return "\x1b[34m"
+	case WARN:
+		return "\x1b[33m"
+	}
+	return "\x1b[31m"
+}
+
+// truncateMiddle shortens s to at most width cells by replacing its middle
+// with an ellipsis, keeping more of the end, which holds the line number.
+func truncateMiddle(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+
+	runes := []rune(s)
+	budget := width - 1
+	headBudget := budget / 2
+	tailBudget := budget - headBudget
+
+	var head []rune
+	used := 0
+	for _, r := range runes {
+		w := runeWidth(r)
+		if used+w > headBudget {
+			break
+		}
+		head = append(head, r)
+		used += w
+	}
+
+	used = 0
+	start := len(runes)
+	for start > len(head) {
+		w := runeWidth(runes[start-1])
+		if used+w > tailBudget {
+			break
+		}
+		start--
+		used += w
+	}
+	return string(head) + "…" + string(runes[start:])
+}
+
+// displayWidth returns the number of terminal cells s occupies.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of terminal cells r occupies: zero for
+// combining marks and other invisible characters, two for wide East Asian
+// characters and emoji, and one otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case r < 0x1100:
+		return 1
+	case r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0x303E, // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33FF, // Kana, CJK symbols
+		r >= 0x3400 && r <= 0x4DBF, // CJK extension A
+		r >= 0x4E00 && r <= 0x9FFF, // CJK unified ideographs
+		r >= 0xA000 && r <= 0xA4CF, // Yi
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F, // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60, // fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // emoji
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions B and later
+		return 2
+	}
+	return 1
+}
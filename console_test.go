@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleFormatterGolden(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		name  string
+		f     ConsoleFormatter
+		entry Entry
+		want  string
+	}{
+		{
+			name:  "short caller",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: INFO, File: "main.go", Line: 12, Message: "started"},
+			want:  "2026-01-02 03:04:05 INFO        main.go:12 started",
+		},
+		{
+			name:  "long caller shortened in the middle",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: WARN, File: "a_very_long_file.go", Line: 140, Message: "slow"},
+			want:  "2026-01-02 03:04:05 WARN  a_very_…e.go:140 slow",
+		},
+		{
+			name:  "caller exactly fitting",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: DEBUG, File: "exactly_16a.go", Line: 1, Message: "m"},
+			want:  "2026-01-02 03:04:05 DEBUG exactly_16a.go:1 m",
+		},
+		{
+			name:  "wide characters count twice",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: ERROR, File: "日本語.go", Line: 7, Message: "失敗"},
+			want:  "2026-01-02 03:04:05 ERR        日本語.go:7 失敗",
+		},
+		{
+			name:  "wide caller shortened to an odd width",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: INFO, File: "文件名称很长的文件.go", Line: 99, Message: "m"},
+			want:  "2026-01-02 03:04:05 INFO   文件名…件.go:99 m",
+		},
+		{
+			name:  "combining marks take no cell",
+			f:     ConsoleFormatter{CallerWidth: 16},
+			entry: Entry{Level: INFO, File: "café.go", Line: 3, Message: "m"},
+			want:  "2026-01-02 03:04:05 INFO         café.go:3 m",
+		},
+		{
+			name:  "default width",
+			entry: Entry{Level: INFO, File: "main.go", Line: 12, Message: "m", ID: "01J0000000000000000000000A"},
+			want:  "2026-01-02 03:04:05 INFO                main.go:12 m id=01J0000000000000000000000A",
+		},
+		{
+			name:  "color",
+			f:     ConsoleFormatter{CallerWidth: 10, Color: true},
+			entry: Entry{Level: WARN, File: "main.go", Line: 12, Message: "m", ID: "01J0000000000000000000000A"},
+			want:  "2026-01-02 03:04:05 \x1b[33mWARN\x1b[0m  main.go:12 m \x1b[2mid=01J0000000000000000000000A\x1b[0m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			e.Time = at
+			if got := tt.f.Format(&e); got != tt.want {
+				t.Errorf("Format =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsoleMessagesAligned(t *testing.T) {
+	f := ConsoleFormatter{CallerWidth: 20}
+	files := []string{"a.go", "main.go", "日本語のファイル名.go", "a_very_long_file_name_indeed.go", "naïve.go", "é́.go", "😀.go"}
+	want := -1
+	for _, file := range files {
+		for _, level := range []LogLevel{DEBUG, INFO, WARN, ERROR} {
+			e := Entry{Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local), Level: level, File: file, Line: 1234, Message: "MSG"}
+			line := f.Format(&e)
+			col := displayWidth(line[:strings.Index(line, "MSG")])
+			if want < 0 {
+				want = col
+			}
+			if col != want {
+				t.Errorf("message of %s at %v starts in column %d, want %d: %q", file, level, col, want, line)
+			}
+		}
+	}
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"main.go:12", 10, "main.go:12"},
+		{"main.go:12", 9, "main…o:12"},
+		{"main.go:12", 2, "…2"},
+		{"main.go:12", 1, "…"},
+		{"main.go:12", 0, ""},
+		{"日本語.go:7", 8, "日…go:7"},
+		{"日本語.go:7", 7, "日…o:7"},
+	}
+	for _, tt := range tests {
+		got := truncateMiddle(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("truncateMiddle(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if w := displayWidth(got); w > tt.width {
+			t.Errorf("truncateMiddle(%q, %d) is %d cells wide", tt.s, tt.width, w)
+		}
+	}
+}
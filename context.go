@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// traceKey is the context key of the trace context added by
+// ContextWithTrace.
+type traceKey struct{}
+
+// traceContext holds the IDs attached with ContextWithTrace.
+type traceContext struct {
+	traceID string
+	spanID  string
+}
+
+// ContextWithTrace returns a copy of ctx carrying the W3C trace context of
+// the current operation: traceID as 32 and spanID as 16 lowercase
+// hexadecimal digits, as in a traceparent header. Entries logged with
+// InfoCtx and the other Ctx functions record them in Entry.TraceID and
+// Entry.SpanID, which TextFormatter writes as " trace=" and " span="
+// tokens and exporters such as otlp map to their own fields.
+//
+// IDs that are malformed or all zeros are not attached.
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	if !isTraceHex(traceID, 32) || !isTraceHex(spanID, 16) {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, traceContext{traceID: traceID, spanID: spanID})
+}
+
+// TraceFromContext returns the trace and span IDs attached to ctx with
+// ContextWithTrace, or empty strings.
+func TraceFromContext(ctx context.Context) (traceID, spanID string) {
+	tc, _ := ctx.Value(traceKey{}).(traceContext)
+	return tc.traceID, tc.spanID
+}
+
+// requestIDKey is the context key of the request ID added by
+// ContextWithRequestID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx identifying the request it
+// belongs to by id. The trigger buffer keeps the entries of every request
+// logged with the Ctx functions apart; see WithTriggerBuffer.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestKey returns the request ID attached to ctx, or traceID if there
+// is none.
+func requestKey(ctx context.Context, traceID string) string {
+	if id, _ := ctx.Value(requestIDKey{}).(string); id != "" {
+		return id
+	}
+	return traceID
+}
+
+// isTraceHex reports whether s is n lowercase hexadecimal digits, not all
+// zero.
+func isTraceHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	zero := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('0' > c || c > '9') && ('a' > c || c > 'f') {
+			return false
+		}
+		zero = zero && c == '0'
+	}
+	return !zero
+}
+
+// DebugCtx logs a message at DEBUG level with the trace context of ctx;
+// see InfoCtx.
+func DebugCtx(ctx context.Context, format string, args ...interface{}) {
+	logCtxAt(ctx, DEBUG, 1, func() string { return fmt.Sprintf(format, args...) })
+}
+
+// InfoCtx logs a message at INFO level like Info, recording the trace and
+// span IDs attached to ctx with ContextWithTrace:
+//
+//	ctx = logger.ContextWithTrace(ctx, traceID, spanID)
+//	logger.InfoCtx(ctx, "charged %s", amount)
+func InfoCtx(ctx context.Context, format string, args ...interface{}) {
+	logCtxAt(ctx, INFO, 1, func() string { return fmt.Sprintf(format, args...) })
+}
+
+// WarnCtx logs a message at WARN level with the trace context of ctx; see
+// InfoCtx.
+func WarnCtx(ctx context.Context, format string, args ...interface{}) {
+	logCtxAt(ctx, WARN, 1, func() string { return fmt.Sprintf(format, args...) })
+}
+
+// ErrorCtx logs a message at ERROR level with the trace context of ctx;
+// see InfoCtx.
+func ErrorCtx(ctx context.Context, format string, args ...interface{}) {
+	logCtxAt(ctx, ERROR, 1, func() string { return fmt.Sprintf(format, args...) })
+}
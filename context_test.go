@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextWithTrace(t *testing.T) {
+	const traceID, spanID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	ctx := context.Background()
+	for _, tt := range []struct{ trace, span string }{
+		{"4BF92F3577B34DA6A3CE929D0E0E4736", spanID},
+		{strings.Repeat("0", 32), spanID},
+		{traceID, "00f067aa0ba902"},
+	} {
+		if got, _ := TraceFromContext(ContextWithTrace(ctx, tt.trace, tt.span)); got != "" {
+			t.Errorf("ContextWithTrace(%q, %q) attached %q", tt.trace, tt.span, got)
+		}
+	}
+
+	dir := initTestLogger(t, "app.log")
+	InfoCtx(ContextWithTrace(ctx, traceID, spanID), "traced")
+	InfoCtx(ctx, "untraced")
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+	if !strings.HasSuffix(lines[0], " - traced trace="+traceID+" span="+spanID) {
+		t.Errorf("traced line = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], " - untraced") {
+		t.Errorf("untraced line = %q", lines[1])
+	}
+}
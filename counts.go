@@ -0,0 +1,58 @@
+package logger
+
+import "sync/atomic"
+
+// levelCounts counts the entries written at every level since start or
+// the last ResetErrorCount.
+var levelCounts [numLevels]atomic.Int64
+
+// countLevels records the levels of es.
+func countLevels(es []Entry) {
+	for i := range es {
+		if l := es[i].Level; l >= DEBUG && l <= ERROR {
+			levelCounts[l-DEBUG].Add(1)
+		}
+	}
+}
+
+// ErrorCount returns the number of ERROR entries logged since the program
+// started or ResetErrorCount was last called.
+func ErrorCount() int64 {
+	return levelCounts[ERROR-DEBUG].Load()
+}
+
+// LevelCount is like ErrorCount for entries at level.
+func LevelCount(level LogLevel) int64 {
+	if level < DEBUG || level > ERROR {
+		return 0
+	}
+	return levelCounts[level-DEBUG].Load()
+}
+
+// ResetErrorCount resets the counts of all levels to zero, for daemons
+// that account for errors per cycle.
+func ResetErrorCount() {
+	for i := range levelCounts {
+		levelCounts[i].Store(0)
+	}
+}
+
+// ExitCode returns failureCode if any ERROR entries were logged and
+// successCode otherwise. Because the arguments of a deferred call are
+// evaluated immediately, wrap it in a function literal so that the code
+// is computed when main returns:
+//
+//	func main() {
+//		defer func() { os.Exit(logger.ExitCode(0, 1)) }()
+//		defer logger.Close()
+//		...
+//	}
+//
+// Deferred calls run in reverse order, so the log is closed before the
+// process exits.
+func ExitCode(successCode, failureCode int) int {
+	if ErrorCount() > 0 {
+		return failureCode
+	}
+	return successCode
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCSVColumns is the column set used by CSVFormatter when Columns is
+// empty.
+var DefaultCSVColumns = []string{"timestamp", "level", "pid", "file", "line", "func", "message"}
+
+// csvColumns maps the supported column names to functions extracting them
+// from an entry.
+var csvColumns = map[string]func(e *Entry) string{
+	"timestamp": func(e *Entry) string { return formatTimestamp(e.Time) },
+	"level":     func(e *Entry) string { return levelName(e.Level) },
+	"pid":       func(e *Entry) string { return strconv.Itoa(e.PID) },
+	"file":      func(e *Entry) string { return e.File },
+	"line":      func(e *Entry) string { return strconv.Itoa(e.Line) },
+	"func":      func(e *Entry) string { return e.Func },
+	"message":   func(e *Entry) string { return e.Message },
+	"id":        func(e *Entry) string { return e.ID },
+}
+
+// CSVFormatter renders entries as CSV records.
+//
+// Values are quoted following encoding/csv rules, so commas, quotes and
+// newlines in messages cannot break the column structure. Columns lists
+// the columns in output order, using the names in DefaultCSVColumns or
+// "id" for the entry ID; when WriteHeader is set, the column names are
+// written as the first row of every new file.
+type CSVFormatter struct {
+	Columns     []string
+	WriteHeader bool
+}
+
+// Format implements Formatter.
+func (f CSVFormatter) Format(e *Entry) string {
+	columns := f.columns()
+	record := make([]string, len(columns))
+	for i, name := range columns {
+		if get, ok := csvColumns[name]; ok {
+			record[i] = get(e)
+		}
+	}
+	return csvRecord(record)
+}
+
+// Header implements HeaderFormatter.
+func (f CSVFormatter) Header() string {
+	if !f.WriteHeader {
+		return ""
+	}
+	return csvRecord(f.columns())
+}
+
+// columns returns the configured columns or the default ones.
+func (f CSVFormatter) columns() []string {
+	if len(f.Columns) == 0 {
+		return DefaultCSVColumns
+	}
+	return f.Columns
+}
+
+// validate reports unknown column names.
+func (f CSVFormatter) validate() error {
+	for _, name := range f.Columns {
+		if _, ok := csvColumns[name]; !ok {
+			return fmt.Errorf("unknown CSV column %q", name)
+		}
+	}
+	return nil
+}
+
+// csvRecord encodes record as a CSV line without the trailing newline.
+func csvRecord(record []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(record)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// adversarialMessages are messages that would break naive CSV output.
+var adversarialMessages = []string{
+	"plain",
+	"a,b,c",
+	`say "hi"`,
+	`"quoted"`,
+	"line one\nline two",
+	"crlf\r\nbreak",
+	"lone\rcarriage return",
+	"=SUM(A1:A9)",
+	"+cmd|' /C calc'!A0",
+	",",
+	`"`,
+	"",
+	" leading and trailing spaces ",
+	"tab\tseparated",
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	dir := initTestLogger(t, "app.csv", WithFormatter(CSVFormatter{
+		Columns:     []string{"level", "line", "message", "func"},
+		WriteHeader: true,
+	}))
+
+	for _, msg := range adversarialMessages {
+		Info("%s", msg)
+	}
+	Close()
+
+	f, err := os.Open(filepath.Join(dir, "app.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 4
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("re-parsing the log: %v", err)
+	}
+
+	if len(records) != len(adversarialMessages)+1 {
+		t.Fatalf("got %d records, want a header and %d entries", len(records), len(adversarialMessages))
+	}
+	if h := records[0]; h[0] != "level" || h[1] != "line" || h[2] != "message" || h[3] != "func" {
+		t.Errorf("header = %q", h)
+	}
+	for i, rec := range records[1:] {
+		want := adversarialMessages[i]
+		if rec[0] != "INFO" || rec[3] != "TestCSVRoundTrip" {
+			t.Errorf("record %d = %q, columns out of place", i, rec)
+		}
+		if _, err := strconv.Atoi(rec[1]); err != nil {
+			t.Errorf("record %d has line %q", i, rec[1])
+		}
+		// csv.Reader turns \r\n within quoted fields into \n.
+		want = strings.ReplaceAll(want, "\r\n", "\n")
+		if rec[2] != want {
+			t.Errorf("record %d message = %q, want %q", i, rec[2], want)
+		}
+	}
+}
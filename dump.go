@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+var dumpLimit atomic.Int64
+
+func init() {
+	dumpLimit.Store(256)
+}
+
+// SetDumpLimit sets how many bytes are included in hex dumps written by
+// DumpHex, TeeReader, TeeWriter and WrapConn. Longer data is truncated with
+// a "... N more bytes" trailer. The default is 256.
+func SetDumpLimit(n int) {
+	dumpLimit.Store(int64(n))
+}
+
+// DumpHex logs data at level as an indented hex dump in the layout of
+// "hexdump -C", 16 bytes per row:
+//
+//	handshake (20 bytes)
+//	  00000000  16 03 01 00 a5 01 00 00  a1 03 03 5e 8b 1c 2a 37  |...........^..*7|
+//	  00000010  9f 7a 05 1e                                       |.z..|
+//
+// The dump is only built when level is enabled for the calling package.
+func DumpHex(level LogLevel, label string, data []byte) {
+	if !enabled(level, 1) {
+		return
+	}
+	message := fmt.Sprintf("%s (%d bytes)\n%s", label, len(data), hexDump(data, int(dumpLimit.Load())))
+	write(newEntry(clockNow(), level, 1, message))
+}
+
+// TeeReader returns a Reader that reads from r and, when DEBUG is enabled,
+// logs every chunk read with its size and a hex dump of its start.
+//
+// The data itself is passed through unchanged, whatever the chunk sizes.
+// Entries are attributed to the caller of TeeReader.
+func TeeReader(r io.Reader, label string) io.Reader {
+	return &teeReader{r: r, label: label, site: captureCallSite(1)}
+}
+
+// TeeWriter returns a Writer that writes to w and, when DEBUG is enabled,
+// logs every chunk written with its size and a hex dump of its start.
+func TeeWriter(w io.Writer, label string) io.Writer {
+	return &teeWriter{w: w, label: label, site: captureCallSite(1)}
+}
+
+// WrapConn returns a net.Conn that dumps both directions of c like
+// TeeReader and TeeWriter.
+func WrapConn(c net.Conn, label string) net.Conn {
+	return &teeConn{Conn: c, label: label, site: captureCallSite(1)}
+}
+
+type teeReader struct {
+	r     io.Reader
+	label string
+	site  callSite
+}
+
+// Read implements io.Reader.
+func (t *teeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	logChunk(t.site, t.label, "read", p[:n])
+	return n, err
+}
+
+type teeWriter struct {
+	w     io.Writer
+	label string
+	site  callSite
+}
+
+// Write implements io.Writer.
+func (t *teeWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	logChunk(t.site, t.label, "write", p[:n])
+	return n, err
+}
+
+type teeConn struct {
+	net.Conn
+	label string
+	site  callSite
+}
+
+// Read implements net.Conn.
+func (c *teeConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	logChunk(c.site, c.label, "read", p[:n])
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *teeConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	logChunk(c.site, c.label, "write", p[:n])
+	return n, err
+}
+
+// logChunk logs a chunk transferred in direction at DEBUG, attributed to
+// site. Nothing is formatted when DEBUG is disabled.
+func logChunk(site callSite, label, direction string, data []byte) {
+	if len(data) == 0 || !enabledAt(DEBUG, site.pc) {
+		return
+	}
+	message := fmt.Sprintf("%s %s %d bytes\n%s", label, direction, len(data), hexDump(data, int(dumpLimit.Load())))
+	write(entryAt(clockNow(), DEBUG, site, message))
+}
+
+// hexDump renders data in the canonical "hexdump -C" layout, indented by
+// two spaces, dumping at most limit bytes. A trailer reports how many bytes
+// were left out.
+func hexDump(data []byte, limit int) string {
+	rest := 0
+	if limit >= 0 && len(data) > limit {
+		rest = len(data) - limit
+		data = data[:limit]
+	}
+
+	var b strings.Builder
+	if len(data) == 0 {
+		b.WriteString("  (empty)")
+	}
+	dump := strings.TrimSuffix(hex.Dump(data), "\n")
+	for _, line := range strings.SplitAfter(dump, "\n") {
+		if line != "" {
+			b.WriteString("  ")
+			b.WriteString(line)
+		}
+	}
+	if rest > 0 {
+		fmt.Fprintf(&b, "\n  ... %d more bytes", rest)
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// headLines returns the messages of the entries in the log file at path,
+// skipping the indented continuation lines holding the dumps.
+func headLines(t *testing.T, path string) []string {
+	t.Helper()
+	var msgs []string
+	for _, line := range readLines(t, path) {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		e, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// TestTeeReaderRoundTrip checks that the data read through a TeeReader is
+// unchanged whatever the chunk sizes, and that the chunks logged add up to
+// all of it.
+func TestTeeReaderRoundTrip(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, tt := range []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"whole", func(r io.Reader) io.Reader { return r }},
+		{"one byte", iotest.OneByteReader},
+		{"half", iotest.HalfReader},
+		{"data with EOF", iotest.DataErrReader},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := initTestLogger(t, "app.log")
+			got, err := io.ReadAll(TeeReader(tt.wrap(bytes.NewReader(data)), "conn"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("read %d bytes differing from the %d written", len(got), len(data))
+			}
+			Close()
+
+			total := 0
+			for _, msg := range headLines(t, filepath.Join(dir, "app.log")) {
+				var n int
+				if _, err := fmt.Sscanf(msg, "conn read %d bytes", &n); err != nil {
+					t.Fatalf("unexpected entry %q", msg)
+				}
+				total += n
+			}
+			if total != len(data) {
+				t.Errorf("logged chunks add up to %d bytes, want %d", total, len(data))
+			}
+		})
+	}
+}
+
+func TestTeeWriterDisabled(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+	SetLevel(INFO)
+
+	var buf bytes.Buffer
+	w := TeeWriter(&buf, "out")
+	for _, chunk := range []string{"hello ", "", "world"} {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("wrote %q, want %q", buf.String(), "hello world")
+	}
+	Close()
+	if lines := readLines(t, filepath.Join(dir, "app.log")); len(lines) != 1 || lines[0] != "" {
+		t.Errorf("logged %q with DEBUG disabled", lines)
+	}
+}
+
+func TestWrapConnDumpsBothDirections(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := WrapConn(client, "peer")
+	go func() {
+		buf := make([]byte, 4)
+		io.ReadFull(server, buf)
+		server.Write([]byte("pong"))
+	}()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "pong" {
+		t.Fatalf("read %q, %v; want pong", buf, err)
+	}
+	conn.Close()
+	Close()
+
+	heads := headLines(t, filepath.Join(dir, "app.log"))
+	want := []string{"peer write 4 bytes", "peer read 4 bytes"}
+	if strings.Join(heads, "|") != strings.Join(want, "|") {
+		t.Errorf("logged %q, want %q", heads, want)
+	}
+}
+
+func TestHexDumpGolden(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		data  string
+		limit int
+		want  string
+	}{
+		{"empty", "", 256, "  (empty)"},
+		{"one byte", "a", 256,
+			"  00000000  61                                                |a|"},
+		{"one row", "0123456789abcdef", 256,
+			"  00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|"},
+		{"partial second row", "0123456789abcdef\x00\xffg\n", 256,
+			"  00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+				"  00000010  00 ff 67 0a                                       |..g.|"},
+		{"at limit", "0123456789", 10,
+			"  00000000  30 31 32 33 34 35 36 37  38 39                    |0123456789|"},
+		{"over limit", "0123456789a", 10,
+			"  00000000  30 31 32 33 34 35 36 37  38 39                    |0123456789|\n" +
+				"  ... 1 more bytes"},
+		{"zero limit", "abc", 0, "  (empty)\n  ... 3 more bytes"},
+		{"no limit", "abc", -1,
+			"  00000000  61 62 63                                          |abc|"},
+	} {
+		if got := hexDump([]byte(tt.data), tt.limit); got != tt.want {
+			t.Errorf("%s: hexDump =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDumpHexTruncated(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+	SetDumpLimit(16)
+	defer SetDumpLimit(256)
+
+	DumpHex(DEBUG, "packet", bytes.Repeat([]byte{0xab}, 20))
+	SetLevel(INFO)
+	DumpHex(DEBUG, "hidden", []byte("x"))
+	Close()
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	want := []string{
+		"  00000000  ab ab ab ab ab ab ab ab  ab ab ab ab ab ab ab ab  |................|",
+		"  ... 4 more bytes",
+	}
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], " - packet (20 bytes)") ||
+		lines[1] != want[0] || lines[2] != want[1] {
+		t.Errorf("logged\n%s\nwant a \"packet (20 bytes)\" entry followed by\n%s",
+			strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
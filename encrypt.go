@@ -0,0 +1,541 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Encrypted files start with a header of encMagic, a version byte, the
+// chunk size and a random nonce prefix. The plaintext follows in chunks of
+// encChunkSize bytes, each sealed with AES-256-GCM and stored behind a
+// 4-byte length whose top bit marks the last chunk. The nonce of a chunk
+// is the nonce prefix, its big-endian index and the last-chunk flag, and
+// the header is authenticated with every chunk, so reordered, truncated or
+// altered files fail to decrypt.
+const (
+	encMagic      = "LENC"
+	encVersion    = 1
+	encChunkSize  = 64 << 10
+	encPrefixSize = 7
+	encHeaderSize = len(encMagic) + 1 + 4 + encPrefixSize
+	encLastChunk  = 1 << 31
+
+	encSuffix = ".enc"
+	tmpSuffix = ".tmp"
+)
+
+// ErrDecrypt is returned when an encrypted log file cannot be decrypted
+// because the key is wrong or the file was truncated or altered.
+var ErrDecrypt = errors.New("logger: cannot decrypt log file: wrong key or damaged file")
+
+// KeyProvider supplies the 32-byte AES-256 key used to encrypt and decrypt
+// rotated log files.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// keyFunc adapts a function to KeyProvider.
+type keyFunc func() ([]byte, error)
+
+func (f keyFunc) Key() ([]byte, error) { return f() }
+
+// StaticKey returns a KeyProvider for a fixed 32-byte key.
+func StaticKey(key []byte) KeyProvider {
+	key = bytes.Clone(key)
+	return keyFunc(func() ([]byte, error) { return checkKey(key) })
+}
+
+// KeyFile returns a KeyProvider reading the key from the file at path on
+// every use, so that it can be replaced without a restart. The file holds
+// the key in hex or base64, or its 32 raw bytes.
+func KeyFile(path string) KeyProvider {
+	return keyFunc(func() ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Text is decoded first, so that an encoded key of the wrong
+		// length is reported instead of being used as raw bytes.
+		if key, ok := decodeKeyText(data); ok {
+			return checkKey(key)
+		}
+		if len(data) == 32 {
+			return data, nil
+		}
+		return nil, errors.New("logger: encryption key file holds neither hex, base64 nor 32 raw bytes")
+	})
+}
+
+// KeyEnv returns a KeyProvider reading the key, in hex or base64, from
+// the environment variable name.
+func KeyEnv(name string) KeyProvider {
+	return keyFunc(func() ([]byte, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("logger: environment variable %s is not set", name)
+		}
+		key, ok := decodeKeyText([]byte(v))
+		if !ok {
+			return nil, fmt.Errorf("logger: environment variable %s is neither hex nor base64", name)
+		}
+		return checkKey(key)
+	})
+}
+
+// decodeKeyText decodes a key given in hex or in base64 and reports
+// whether data was in either encoding.
+func decodeKeyText(data []byte) ([]byte, bool) {
+	s := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(s); err == nil {
+		return key, true
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return key, true
+	}
+	return nil, false
+}
+
+// checkKey verifies that key has the length of an AES-256 key.
+func checkKey(key []byte) ([]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("logger: encryption key has %d bytes, want 32", len(key))
+	}
+	return key, nil
+}
+
+// newGCM returns AES-256-GCM for the key of keys.
+func newGCM(keys KeyProvider) (cipher.AEAD, error) {
+	key, err := keys.Key()
+	if err != nil {
+		return nil, err
+	}
+	if key, err = checkKey(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptRotated returns a callback for OnRotate encrypting each rotated
+// file with EncryptLogFile, so that archives are encrypted at rest while
+// the active file stays readable. If an earlier hook compressed the file
+// to oldPath + ".gz", the compressed file is encrypted. Failures are
+// reported to the error handler and leave the plaintext in place.
+//
+//	logger.OnRotate(compress)
+//	logger.OnRotate(logger.EncryptRotated(logger.KeyEnv("LOG_KEY")))
+//
+// Call ResumeEncryption at startup to finish encryptions interrupted by a
+// crash.
+func EncryptRotated(keys KeyProvider) func(oldPath, newPath string) {
+	return func(oldPath, newPath string) {
+		src := oldPath
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			src += ".gz"
+		}
+		if _, err := EncryptLogFile(src, keys); err != nil {
+			reportError(fmt.Errorf("logger: failed to encrypt %s: %w", src, err))
+		}
+	}
+}
+
+// EncryptLogFile encrypts the file at src to src + ".enc" and returns the
+// new path. The encrypted file is written under a temporary name and
+// decrypted again to check it against src before it is renamed into
+// place; only then is src deleted.
+func EncryptLogFile(src string, keys KeyProvider) (string, error) {
+	aead, err := newGCM(keys)
+	if err != nil {
+		return "", err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	dst := src + encSuffix
+	tmp := dst + tmpSuffix
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.New()
+	w, err := newEncryptWriter(out, aead)
+	if err == nil {
+		_, err = io.Copy(w, io.TeeReader(in, sum))
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = verifyEncrypted(tmp, aead, sum.Sum(nil))
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	in.Close()
+	return dst, os.Remove(src)
+}
+
+// verifyEncrypted checks that the file at path decrypts to plaintext with
+// the SHA-256 digest want.
+func verifyEncrypted(path string, aead cipher.AEAD, want []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := newDecryptReader(f, aead)
+	if err != nil {
+		return err
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return err
+	}
+	if !bytes.Equal(sum.Sum(nil), want) {
+		return fmt.Errorf("logger: %s does not match the file it was encrypted from", path)
+	}
+	return nil
+}
+
+// ResumeEncryption finishes the encryption of the rotated files matching
+// the glob pattern, such as "logs/app-*.log*", after a crash. Leftover
+// temporary files are deleted and their files encrypted again, files that
+// were encrypted but not yet deleted are checked against their encrypted
+// copy and deleted, and files never encrypted are encrypted. A file whose
+// name + ".gz" also exists is left alone, since its compression did not
+// finish. The pattern must not match the active log file. It is meant to
+// run at startup, before logging.
+func ResumeEncryption(pattern string, keys KeyProvider) error {
+	aead, err := newGCM(keys)
+	if err != nil {
+		return err
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+
+	var errs []error
+	var plain []string
+	for _, name := range matches {
+		switch {
+		case strings.HasSuffix(name, encSuffix+tmpSuffix):
+			if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		case strings.HasSuffix(name, encSuffix), !isLogFileName(name):
+		default:
+			plain = append(plain, name)
+		}
+	}
+
+	for _, name := range plain {
+		if _, err := os.Stat(name + ".gz"); err == nil {
+			continue
+		}
+		if err := resumeFile(name, aead, keys); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resumeFile deletes the plaintext name if it has a complete encrypted
+// copy and encrypts it otherwise.
+func resumeFile(name string, aead cipher.AEAD, keys KeyProvider) error {
+	if _, err := os.Stat(name + encSuffix); err == nil {
+		sum, err := fileDigest(name)
+		if err != nil {
+			return err
+		}
+		if verifyEncrypted(name+encSuffix, aead, sum) == nil {
+			return os.Remove(name)
+		}
+		if err := os.Remove(name + encSuffix); err != nil {
+			return err
+		}
+	}
+	_, err := EncryptLogFile(name, keys)
+	return err
+}
+
+// fileDigest returns the SHA-256 digest of the file at path.
+func fileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return nil, err
+	}
+	return sum.Sum(nil), nil
+}
+
+// DecryptLogFile decrypts the file at src, written by EncryptLogFile, to
+// dst. The output is written under a temporary name and renamed to dst
+// once the whole file has been authenticated, so a damaged file never
+// yields a partial dst; ErrDecrypt is returned instead.
+func DecryptLogFile(src, dst string, keys KeyProvider) error {
+	aead, err := newGCM(keys)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + tmpSuffix
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	r, err := newDecryptReader(in, aead)
+	if err == nil {
+		_, err = io.Copy(out, r)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// NewDecryptReader returns a reader of the plaintext of an encrypted log
+// file read from r, for example to pass to NewScanner. Each chunk is
+// authenticated before it is returned; Read fails with ErrDecrypt at the
+// first damaged chunk.
+func NewDecryptReader(r io.Reader, keys KeyProvider) (io.Reader, error) {
+	aead, err := newGCM(keys)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, aead)
+}
+
+// encryptWriter seals the data written to it in chunks.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	nonce  []byte
+	index  uint32
+	buf    []byte
+	out    []byte
+}
+
+// newEncryptWriter writes the header of a new encrypted file to w.
+func newEncryptWriter(w io.Writer, aead cipher.AEAD) (*encryptWriter, error) {
+	header := make([]byte, 0, encHeaderSize)
+	header = append(header, encMagic...)
+	header = append(header, encVersion)
+	header = binary.BigEndian.AppendUint32(header, encChunkSize)
+	prefix := make([]byte, encPrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	header = append(header, prefix...)
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		nonce:  append(prefix, make([]byte, aead.NonceSize()-encPrefixSize)...),
+		buf:    make([]byte, 0, encChunkSize),
+		out:    make([]byte, 4, 4+encChunkSize+aead.Overhead()),
+	}, nil
+}
+
+// Write implements io.Writer. A full chunk is only sealed once more data
+// follows, since the last chunk is sealed differently by Close.
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if len(ew.buf) == encChunkSize {
+			if err := ew.seal(false); err != nil {
+				return n - len(p), err
+			}
+		}
+		c := copy(ew.buf[len(ew.buf):encChunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+c]
+		p = p[c:]
+	}
+	return n, nil
+}
+
+// Close seals the last chunk, which may be empty.
+func (ew *encryptWriter) Close() error {
+	return ew.seal(true)
+}
+
+// seal encrypts and writes the buffered chunk.
+func (ew *encryptWriter) seal(last bool) error {
+	if ew.index == 1<<32-1 {
+		return errors.New("logger: file too large to encrypt")
+	}
+	setChunkNonce(ew.nonce, ew.index, last)
+	ew.out = ew.aead.Seal(ew.out[:4], ew.nonce, ew.buf, ew.header)
+	size := uint32(len(ew.out) - 4)
+	if last {
+		size |= encLastChunk
+	}
+	binary.BigEndian.PutUint32(ew.out, size)
+	if _, err := ew.w.Write(ew.out); err != nil {
+		return err
+	}
+	ew.index++
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+// setChunkNonce sets the chunk index and last flag after the nonce prefix.
+func setChunkNonce(nonce []byte, index uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[encPrefixSize:], index)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+// decryptReader opens the chunks of an encrypted file.
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	nonce  []byte
+	index  uint32
+	chunk  []byte
+	plain  []byte
+	done   bool
+	err    error
+}
+
+// newDecryptReader reads and checks the header of an encrypted file.
+func newDecryptReader(r io.Reader, aead cipher.AEAD) (*decryptReader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, encHeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: missing header", ErrDecrypt)
+	}
+	if string(header[:len(encMagic)]) != encMagic {
+		return nil, fmt.Errorf("%w: not an encrypted log file", ErrDecrypt)
+	}
+	if v := header[len(encMagic)]; v != encVersion {
+		return nil, fmt.Errorf("logger: unsupported encrypted log file version %d", v)
+	}
+	if binary.BigEndian.Uint32(header[len(encMagic)+1:]) != encChunkSize {
+		return nil, fmt.Errorf("%w: bad chunk size", ErrDecrypt)
+	}
+	prefix := header[encHeaderSize-encPrefixSize:]
+
+	return &decryptReader{
+		r:      br,
+		aead:   aead,
+		header: header,
+		nonce:  append(bytes.Clone(prefix), make([]byte, aead.NonceSize()-encPrefixSize)...),
+	}, nil
+}
+
+// Read implements io.Reader.
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.done {
+			return 0, io.EOF
+		}
+		dr.err = dr.next()
+	}
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
+
+// next reads and opens the next chunk.
+func (dr *decryptReader) next() error {
+	var prefix [4]byte
+	if _, err := io.ReadFull(dr.r, prefix[:]); err != nil {
+		return fmt.Errorf("%w: truncated", ErrDecrypt)
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	last := size&encLastChunk != 0
+	size &^= encLastChunk
+	if size > encChunkSize+uint32(dr.aead.Overhead()) {
+		return fmt.Errorf("%w: bad chunk length", ErrDecrypt)
+	}
+
+	if cap(dr.chunk) < int(size) {
+		dr.chunk = make([]byte, size)
+	}
+	dr.chunk = dr.chunk[:size]
+	if _, err := io.ReadFull(dr.r, dr.chunk); err != nil {
+		return fmt.Errorf("%w: truncated", ErrDecrypt)
+	}
+
+	setChunkNonce(dr.nonce, dr.index, last)
+	plain, err := dr.aead.Open(dr.chunk[:0], dr.nonce, dr.chunk, dr.header)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d failed authentication", ErrDecrypt, dr.index)
+	}
+	dr.index++
+	dr.plain = plain
+
+	if last {
+		if _, err := dr.r.ReadByte(); err != io.EOF {
+			return fmt.Errorf("%w: data after the last chunk", ErrDecrypt)
+		}
+		dr.done = true
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = StaticKey(bytes.Repeat([]byte{0x42}, 32))
+
+// writeTestFile writes data to name in dir and returns its path.
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// encryptTestFile encrypts data and returns the path of the encrypted
+// file.
+func encryptTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	src := writeTestFile(t, t.TempDir(), "app-2026-01-02T03-04-05.000.log", data)
+	dst, err := EncryptLogFile(src, testKey)
+	if err != nil {
+		t.Fatalf("EncryptLogFile: %v", err)
+	}
+	return dst
+}
+
+func TestKeyEncodings(t *testing.T) {
+	key := bytes.Repeat([]byte{0xa5}, 32)
+	hexKey := strings.Repeat("a5", 32)
+	for _, tt := range []struct {
+		name    string
+		value   string
+		file    bool
+		wantErr string
+	}{
+		{"hex", hexKey, false, ""},
+		{"base64", "paWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaWlpaU=", false, ""},
+		{"hex with newline", hexKey + "\n", true, ""},
+		{"raw file", string(key), true, ""},
+		{"short hex", hexKey[:32], false, "16 bytes"},
+		{"short hex file", hexKey[:32], true, "16 bytes"},
+		{"32 characters", "this is not hex or base64 text!!", false, "neither hex nor base64"},
+	} {
+		var keys KeyProvider
+		if tt.file {
+			keys = KeyFile(writeTestFile(t, t.TempDir(), "key", []byte(tt.value)))
+		} else {
+			t.Setenv("LOG_TEST_KEY", tt.value)
+			keys = KeyEnv("LOG_TEST_KEY")
+		}
+		got, err := keys.Key()
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Key() = %x, %v; want an error containing %q", tt.name, got, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: Key(): %v", tt.name, err)
+		case !bytes.Equal(got, key):
+			t.Errorf("%s: Key() = %x, want %x", tt.name, got, key)
+		}
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 100, encChunkSize, 2*encChunkSize + 17} {
+		data := bytes.Repeat([]byte("entry\n"), size/6+1)[:size]
+		enc := encryptTestFile(t, data)
+		if _, err := os.Stat(strings.TrimSuffix(enc, encSuffix)); !os.IsNotExist(err) {
+			t.Errorf("size %d: plaintext kept after encryption: %v", size, err)
+		}
+
+		out := filepath.Join(t.TempDir(), "plain.log")
+		if err := DecryptLogFile(enc, out, testKey); err != nil {
+			t.Fatalf("size %d: DecryptLogFile: %v", size, err)
+		}
+		if got, _ := os.ReadFile(out); !bytes.Equal(got, data) {
+			t.Errorf("size %d: decrypted %d bytes that differ from the original", size, len(got))
+		}
+	}
+}
+
+func TestEncryptNoncesDiffer(t *testing.T) {
+	data := []byte("same plaintext\n")
+	a, _ := os.ReadFile(encryptTestFile(t, data))
+	b, _ := os.ReadFile(encryptTestFile(t, data))
+	if bytes.Equal(a[:encHeaderSize], b[:encHeaderSize]) {
+		t.Error("two files share the same nonce prefix")
+	}
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), encChunkSize/8)
+	enc := encryptTestFile(t, data)
+	orig, err := os.ReadFile(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(i int) []byte {
+		b := bytes.Clone(orig)
+		b[i] ^= 1
+		return b
+	}
+	for name, damaged := range map[string][]byte{
+		"header":         flip(len(encMagic) + 2),
+		"nonce":          flip(encHeaderSize - 1),
+		"first chunk":    flip(encHeaderSize + 100),
+		"last chunk":     flip(len(orig) - 1),
+		"truncated":      orig[:len(orig)-100],
+		"last dropped":   orig[:encHeaderSize+4+encChunkSize+16],
+		"extra appended": append(bytes.Clone(orig), 0, 0, 0, 0),
+	} {
+		path := writeTestFile(t, t.TempDir(), "app.log.enc", damaged)
+		out := filepath.Join(t.TempDir(), "plain.log")
+		if err := DecryptLogFile(path, out, testKey); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: DecryptLogFile = %v, want ErrDecrypt", name, err)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("%s: partial output left behind", name)
+		}
+	}
+
+	wrong := StaticKey(bytes.Repeat([]byte{0x24}, 32))
+	if err := DecryptLogFile(enc, filepath.Join(t.TempDir(), "plain.log"), wrong); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong key: DecryptLogFile = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestResumeEncryption(t *testing.T) {
+	dir := t.TempDir()
+	data := func(name string) []byte { return []byte("entries of " + name + "\n") }
+
+	// Crashed while writing the encrypted copy.
+	partial := writeTestFile(t, dir, "app-2026-01-01T00-00-00.000.log", data("partial"))
+	writeTestFile(t, dir, "app-2026-01-01T00-00-00.000.log.enc.tmp", []byte("LENC garbage"))
+
+	// Crashed after the rename, before the plaintext was deleted.
+	renamed := writeTestFile(t, dir, "app-2026-01-02T00-00-00.000.log", data("renamed"))
+	if _, err := EncryptLogFile(renamed, testKey); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, filepath.Base(renamed), data("renamed"))
+
+	// Encrypted copy does not match its plaintext and must be redone.
+	stale := writeTestFile(t, dir, "app-2026-01-03T00-00-00.000.log", data("stale"))
+	writeTestFile(t, dir, filepath.Base(stale)+encSuffix, []byte("LENC damaged"))
+
+	// Compression has not finished; left for the next run.
+	compressing := writeTestFile(t, dir, "app-2026-01-04T00-00-00.000.log", data("compressing"))
+	writeTestFile(t, dir, filepath.Base(compressing)+".gz", []byte("partial gzip"))
+
+	if err := ResumeEncryption(filepath.Join(dir, "app-*.log*"), testKey); err != nil {
+		t.Fatalf("ResumeEncryption: %v", err)
+	}
+
+	for path, name := range map[string]string{partial: "partial", renamed: "renamed", stale: "stale"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: plaintext kept: %v", filepath.Base(path), err)
+		}
+		out := filepath.Join(t.TempDir(), "plain.log")
+		if err := DecryptLogFile(path+encSuffix, out, testKey); err != nil {
+			t.Errorf("%s: %v", filepath.Base(path), err)
+			continue
+		}
+		if got, _ := os.ReadFile(out); !bytes.Equal(got, data(name)) {
+			t.Errorf("%s: decrypted %q", filepath.Base(path), got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-2026-01-01T00-00-00.000.log.enc.tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary file kept: %v", err)
+	}
+	if _, err := os.Stat(compressing); err != nil {
+		t.Errorf("file still being compressed was touched: %v", err)
+	}
+}
+
+func TestQueryReadsEncryptedBackups(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	Info("archived entry")
+	clock.Advance(time.Second)
+	backup, err := Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptLogFile(backup, testKey); err != nil {
+		t.Fatal(err)
+	}
+	Info("active entry")
+
+	for _, tt := range []struct {
+		keys KeyProvider
+		want []string
+	}{
+		{nil, []string{"active entry"}},
+		{testKey, []string{"archived entry", "active entry"}},
+	} {
+		entries, err := Query(dir, QueryOptions{Keys: tt.keys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Message)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Query with keys %v = %q, want %q", tt.keys != nil, got, tt.want)
+		}
+	}
+}
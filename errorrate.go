@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorRateAlert watches the number of ERROR entries in a sliding window;
+// see OnErrorRate.
+type ErrorRateAlert struct {
+	threshold int
+	window    time.Duration
+	fired     func(count int)
+
+	mu        sync.Mutex
+	buckets   []rateBucket
+	recovered func(count int)
+	alerting  bool
+	lastFired time.Time
+
+	kick chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+// rateBucket counts the errors logged during one second.
+type rateBucket struct {
+	sec   int64
+	count int
+}
+
+var (
+	alertsMu sync.Mutex
+	alerts   atomic.Pointer[[]*ErrorRateAlert]
+)
+
+// OnErrorRate calls fn when threshold or more ERROR entries were logged
+// within the last window, which is rounded up to whole seconds.
+//
+// fn is called when the rate first crosses the threshold, and again at
+// most once per window while it stays above it. Use Recovered on the
+// returned alert to be told when the rate falls back below the threshold.
+// Callbacks run on a goroutine of the alert and never block logging.
+// Errors are counted in the second of their entry's Time.
+//
+// OnErrorRate panics if threshold is not positive or fn is nil.
+func OnErrorRate(threshold int, window time.Duration, fn func(count int)) *ErrorRateAlert {
+	if threshold <= 0 {
+		panic("logger: OnErrorRate requires a positive threshold")
+	}
+	if fn == nil {
+		panic("logger: OnErrorRate requires a callback")
+	}
+	n := int((window + time.Second - 1) / time.Second)
+	if n < 1 {
+		n = 1
+	}
+	a := &ErrorRateAlert{
+		threshold: threshold,
+		window:    time.Duration(n) * time.Second,
+		fired:     fn,
+		buckets:   make([]rateBucket, n),
+		kick:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+
+	alertsMu.Lock()
+	var list []*ErrorRateAlert
+	if p := alerts.Load(); p != nil {
+		list = append(list, *p...)
+	}
+	list = append(list, a)
+	alerts.Store(&list)
+	alertsMu.Unlock()
+
+	go a.run()
+	return a
+}
+
+// Recovered registers fn to be called with the current count when the
+// error rate falls back below the threshold after the alert fired. It
+// returns a for chaining.
+func (a *ErrorRateAlert) Recovered(fn func(count int)) *ErrorRateAlert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recovered = fn
+	return a
+}
+
+// Stop stops watching the error rate and ends the goroutine of the alert.
+// A callback that is already due may still run once.
+func (a *ErrorRateAlert) Stop() {
+	alertsMu.Lock()
+	if p := alerts.Load(); p != nil {
+		var list []*ErrorRateAlert
+		for _, other := range *p {
+			if other != a {
+				list = append(list, other)
+			}
+		}
+		alerts.Store(&list)
+	}
+	alertsMu.Unlock()
+
+	a.once.Do(func() { close(a.stop) })
+}
+
+// countErrors records the ERROR entries among es with every alert, in the
+// seconds of their timestamps.
+func countErrors(es []Entry) {
+	p := alerts.Load()
+	if p == nil || len(*p) == 0 {
+		return
+	}
+
+	var sec int64
+	n := 0
+	for i := range es {
+		if es[i].Level < ERROR {
+			continue
+		}
+		if s := es[i].Time.Unix(); n > 0 && s != sec {
+			for _, a := range *p {
+				a.add(sec, n)
+			}
+			n = 0
+		}
+		sec = es[i].Time.Unix()
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	for _, a := range *p {
+		a.add(sec, n)
+	}
+}
+
+// add records n errors logged in the second sec of Unix time and wakes the
+// alert goroutine.
+func (a *ErrorRateAlert) add(sec int64, n int) {
+	a.mu.Lock()
+	b := &a.buckets[int(sec%int64(len(a.buckets)))]
+	switch {
+	case b.sec > sec:
+		// The entry is older than the window; the bucket holds a later second.
+	case b.sec < sec:
+		b.sec = sec
+		b.count = n
+	default:
+		b.count += n
+	}
+	a.mu.Unlock()
+
+	select {
+	case a.kick <- struct{}{}:
+	default:
+	}
+}
+
+// run evaluates the alert whenever errors are logged and once a second,
+// so that recovery is noticed while no errors arrive.
+func (a *ErrorRateAlert) run() {
+	t := newClockTimer(time.Second)
+	for {
+		select {
+		case <-a.kick:
+		case <-t.C():
+			t = newClockTimer(time.Second)
+		case <-a.stop:
+			t.Stop()
+			return
+		}
+		a.evaluate(clockNow())
+	}
+}
+
+// evaluate calls the callbacks whose condition holds at now.
+func (a *ErrorRateAlert) evaluate(now time.Time) {
+	a.mu.Lock()
+	count := 0
+	for _, b := range a.buckets {
+		if now.Unix()-b.sec < int64(len(a.buckets)) {
+			count += b.count
+		}
+	}
+
+	var fn func(int)
+	switch {
+	case count >= a.threshold && (!a.alerting || now.Sub(a.lastFired) >= a.window):
+		a.alerting = true
+		a.lastFired = now
+		fn = a.fired
+	case count < a.threshold && a.alerting:
+		a.alerting = false
+		fn = a.recovered
+	}
+	a.mu.Unlock()
+
+	if fn != nil {
+		callRateHook(fn, count)
+	}
+}
+
+// callRateHook calls fn, reporting a panic to the error handler.
+func callRateHook(fn func(count int), count int) {
+	defer func() {
+		if p := recover(); p != nil {
+			reportError(fmt.Errorf("logger: error rate callback panicked: %v", p))
+		}
+	}()
+	fn(count)
+}
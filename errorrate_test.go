@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnErrorRateRejectsInvalidArguments(t *testing.T) {
+	for name, f := range map[string]func(){
+		"zero threshold": func() { OnErrorRate(0, time.Minute, func(int) {}) },
+		"nil callback":   func() { OnErrorRate(1, time.Minute, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("OnErrorRate did not panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestErrorRateCountsEntryTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	useFakeClock(t, now)
+
+	var fired atomic.Int32
+	a := OnErrorRate(2, 10*time.Second, func(int) { fired.Add(1) })
+	defer a.Stop()
+
+	old := now.Add(-30 * time.Second)
+	countErrors([]Entry{{Level: ERROR, Time: old}, {Level: ERROR, Time: old}})
+	a.evaluate(now)
+	if n := fired.Load(); n != 0 {
+		t.Fatalf("alert fired %d times for errors outside the window", n)
+	}
+
+	countErrors([]Entry{{Level: ERROR, Time: now.Add(-time.Second)}, {Level: INFO, Time: now}, {Level: ERROR, Time: now}})
+	a.evaluate(now)
+	if n := fired.Load(); n != 1 {
+		t.Fatalf("alert fired %d times, want once", n)
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrClosed is reported to the error handler when entries are logged
+// after Close.
+var ErrClosed = errors.New("logger: closed")
+
+var errorHandler atomic.Pointer[func(error)]
+
+// SetErrorHandler registers a function that is called when the logger
+// itself runs into a problem, such as an entry being logged after Close.
+//
+// The handler is called outside of the logger's internal locks, so it may
+// log. Passing nil removes the handler.
+func SetErrorHandler(fn func(error)) {
+	if fn == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&fn)
+}
+
+// reportError passes err to the registered error handler, if any.
+func reportError(err error) {
+	if fn := errorHandler.Load(); fn != nil {
+		(*fn)(err)
+	}
+}
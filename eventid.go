@@ -0,0 +1,77 @@
+package logger
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand/v2"
+	"time"
+)
+
+// WithEventIDs stamps every entry with a ULID: a 26 character identifier
+// that sorts by the time of the entry, so that the same event can be
+// recognized in every system its line is forwarded to. IDs are assigned in
+// the order entries are written and increase strictly, also within the
+// same millisecond.
+func WithEventIDs() Option {
+	return func(c *config) {
+		c.eventIDs = true
+	}
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ulidSource *rand.ChaCha8
+	ulidMillis uint64
+	ulidHigh   uint16
+	ulidLow    uint64
+)
+
+// nextEventID returns a new ULID for an entry logged at t. IDs increase
+// strictly: within the same millisecond, or when t is earlier than the
+// previous entry, the random part of the previous ID is incremented. The
+// caller must hold mu.
+func nextEventID(t time.Time) string {
+	if ulidSource == nil {
+		var seed [32]byte
+		crand.Read(seed[:])
+		ulidSource = rand.NewChaCha8(seed)
+	}
+
+	ms := uint64(t.UnixMilli())
+	if ms > ulidMillis {
+		ulidMillis = ms
+		ulidHigh = uint16(ulidSource.Uint64())
+		ulidLow = ulidSource.Uint64()
+	} else {
+		ulidLow++
+		if ulidLow == 0 {
+			ulidHigh++
+			if ulidHigh == 0 {
+				// The random part overflowed; borrow from the next
+				// millisecond.
+				ulidMillis++
+			}
+		}
+	}
+
+	var raw [16]byte
+	binary.BigEndian.PutUint64(raw[0:8], ulidMillis<<16|uint64(ulidHigh))
+	binary.BigEndian.PutUint64(raw[8:16], ulidLow)
+	return encodeULID(raw)
+}
+
+// encodeULID renders the 128 bits of raw as 26 base32 characters.
+func encodeULID(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[0:8])
+	lo := binary.BigEndian.Uint64(raw[8:16])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
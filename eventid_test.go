@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ulidMillisOf decodes the timestamp part of a ULID.
+func ulidMillisOf(id string) int64 {
+	var ms int64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | int64(strings.IndexByte(crockford, id[i]))
+	}
+	return ms
+}
+
+func TestEventIDsConcurrent(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 7, 1, 12, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log", WithEventIDs())
+
+	const goroutines, perGoroutine = 8, 250
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				Info("g%d %d", g, i)
+				if g == 0 && i%50 == 0 {
+					// Most IDs share a millisecond; some cross into the next.
+					clock.Advance(time.Millisecond)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	if len(es) != goroutines*perGoroutine {
+		t.Fatalf("got %d entries, want %d", len(es), goroutines*perGoroutine)
+	}
+	seen := make(map[string]bool, len(es))
+	var prev string
+	for i, e := range es {
+		if !isEventID(e.ID) {
+			t.Fatalf("entry %d has ID %q", i, e.ID)
+		}
+		if seen[e.ID] {
+			t.Fatalf("ID %s assigned twice", e.ID)
+		}
+		seen[e.ID] = true
+		if e.ID <= prev {
+			t.Fatalf("entry %d has ID %s after %s, want IDs increasing in file order", i, e.ID, prev)
+		}
+		prev = e.ID
+		// The text timestamp is truncated to its layout's precision.
+		if d := ulidMillisOf(e.ID) - e.Time.UnixMilli(); d < 0 || d >= 1000 {
+			t.Errorf("entry %d logged at %v has an ID %d ms later", i, e.Time, d)
+		}
+	}
+}
+
+func TestEventIDsMonotonicWithinMillisecond(t *testing.T) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.Local)
+	prev := nextEventID(now)
+	for i := 0; i < 10000; i++ {
+		// Time going backwards keeps the IDs increasing as well.
+		at := now
+		if i%100 == 0 {
+			at = now.Add(-time.Second)
+		}
+		id := nextEventID(at)
+		if id <= prev {
+			t.Fatalf("ID %s after %s", id, prev)
+		}
+		prev = id
+	}
+}
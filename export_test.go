@@ -0,0 +1,7 @@
+package logger
+
+// ResetCallerSkipPrefixes forgets the prefixes registered with
+// RegisterCallerSkipPrefix, for tests in other packages.
+func ResetCallerSkipPrefixes() {
+	skipPrefixes.Store(nil)
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// WithFallback adds outputs to fall back to when the primary output, the
+// log file or the writer passed to SetWriter, fails.
+//
+// Each entry is written to the first healthy output in order: the primary,
+// then the fallbacks as given. An output that fails a write is skipped for
+// probeInterval, after which the next entry tries it again, so logging
+// returns to the primary once it recovers. The entry that failed is
+// written to the next output, and every switch is recorded with a marker
+// entry on the output taking over. A zero probeInterval defaults to 5
+// seconds. The logger does not close the fallback outputs.
+func WithFallback(probeInterval time.Duration, outputs ...io.Writer) Option {
+	return func(c *config) {
+		if probeInterval <= 0 {
+			probeInterval = 5 * time.Second
+		}
+		c.fallbacks = outputs
+		c.probeInterval = probeInterval
+	}
+}
+
+var (
+	// outputDown holds, for the primary and every fallback output, when
+	// it last failed, or the zero time while it is healthy.
+	outputDown []time.Time
+
+	// activeOutput is the index of the output entries are written to.
+	activeOutput int
+)
+
+// resetFallback forgets the health of the outputs. The caller must hold mu.
+func resetFallback() {
+	outputDown = nil
+	activeOutput = 0
+}
+
+// writeFallback writes rec, an entry logged at now, to the first healthy
+// output. The caller must hold mu.
+func writeFallback(now time.Time, rec []byte) error {
+	if len(outputDown) != len(cfg.fallbacks)+1 {
+		outputDown = make([]time.Time, len(cfg.fallbacks)+1)
+	}
+
+	var lastErr error
+	for i := range outputDown {
+		if !outputDown[i].IsZero() && now.Sub(outputDown[i]) < cfg.probeInterval {
+			continue
+		}
+
+		w := logger.Writer()
+		if i > 0 {
+			w = cfg.fallbacks[i-1]
+		}
+
+		err := switchOutput(w, i, lastErr)
+		if err == nil {
+			_, err = w.Write(rec)
+		}
+		if err != nil {
+			outputDown[i] = now
+			lastErr = err
+			continue
+		}
+		outputDown[i] = time.Time{}
+		return nil
+	}
+	if lastErr == nil {
+		return fmt.Errorf("logger: no healthy output")
+	}
+	return lastErr
+}
+
+// switchOutput makes output i, written through w, the active one, writing
+// a marker entry explaining the switch to it first. cause is the last
+// error of the outputs tried before it, if any. Nothing is written when i already
+// is the active output.
+func switchOutput(w io.Writer, i int, cause error) error {
+	if i == activeOutput {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s recovered, switching back from %s", outputName(i), outputName(activeOutput))
+	if i > activeOutput {
+		msg = fmt.Sprintf("%s failed, switching to %s", outputName(activeOutput), outputName(i))
+		if cause != nil {
+			msg += ": " + cause.Error()
+		}
+	}
+	e := newEntry(clockNow(), WARN, 1, msg)
+	if _, err := w.Write(encodeEntry(&e)); err != nil {
+		return err
+	}
+	activeOutput = i
+	return nil
+}
+
+// outputName describes output i in marker entries.
+func outputName(i int) string {
+	if i == 0 {
+		return "primary output"
+	}
+	return fmt.Sprintf("fallback output %d", i)
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyWriter collects writes in memory and fails them while down is set.
+type flakyWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	down bool
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.down {
+		return 0, errors.New("device unavailable")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) setDown(down bool) {
+	w.mu.Lock()
+	w.down = down
+	w.mu.Unlock()
+}
+
+// entries parses the entries written to w.
+func (w *flakyWriter) entries(t *testing.T) []Entry {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var es []Entry
+	for _, line := range strings.Split(strings.TrimSuffix(w.buf.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		e, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+		es = append(es, e)
+	}
+	return es
+}
+
+func TestFallbackWithoutGaps(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local))
+	primary, fallback := &flakyWriter{}, &flakyWriter{}
+	initTestLogger(t, "app.log", WithFallback(time.Minute, fallback))
+	if err := SetWriter(primary); err != nil {
+		t.Fatal(err)
+	}
+
+	const total = 300
+	for i := 0; i < total; i++ {
+		switch i {
+		case 100:
+			primary.setDown(true)
+		case 200:
+			primary.setDown(false)
+			clock.Advance(time.Minute)
+		}
+		Info("entry %d", i)
+	}
+
+	var got []int
+	var markers []string
+	for _, w := range []*flakyWriter{primary, fallback} {
+		for _, e := range w.entries(t) {
+			if n, ok := strings.CutPrefix(e.Message, "entry "); ok {
+				i, err := strconv.Atoi(n)
+				if err != nil {
+					t.Fatalf("bad entry %q", e.Message)
+				}
+				got = append(got, i)
+			} else {
+				markers = append(markers, e.Message)
+			}
+		}
+	}
+
+	seen := make(map[int]int)
+	for _, i := range got {
+		seen[i]++
+	}
+	for i := 0; i < total; i++ {
+		if seen[i] != 1 {
+			t.Errorf("entry %d written %d times", i, seen[i])
+		}
+	}
+
+	fb := fallback.entries(t)
+	if len(fb) == 0 || !strings.HasPrefix(fb[0].Message, "primary output failed, switching to fallback output 1: device unavailable") {
+		t.Fatalf("fallback starts with %+v, want the switch marker", fb)
+	}
+	for i, e := range fb[1:] {
+		if want := "entry " + strconv.Itoa(100+i); e.Message != want {
+			t.Fatalf("fallback entry %d = %q, want %q", i, e.Message, want)
+		}
+	}
+	if len(fb) != 101 {
+		t.Errorf("fallback holds %d entries, want the marker and entries 100 to 199", len(fb))
+	}
+	if !containsString(markers, "primary output recovered, switching back from fallback output 1") {
+		t.Errorf("markers %q lack the recovery", markers)
+	}
+}
+
+// TestFailedInitKeepsFallbackState checks that an InitLogger call failing
+// to open its file leaves the running logger on the fallback it switched
+// to, instead of probing the failed primary output again.
+func TestFailedInitKeepsFallbackState(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local))
+	primary, fallback := &flakyWriter{}, &flakyWriter{}
+	dir := initTestLogger(t, "app.log", WithFallback(time.Minute, fallback))
+	if err := SetWriter(primary); err != nil {
+		t.Fatal(err)
+	}
+
+	primary.setDown(true)
+	Info("before")
+	if err := InitLogger(filepath.Join(dir, "app.log", "nested.log"), WithFallback(time.Minute, fallback)); err == nil {
+		t.Fatal("InitLogger succeeded below a regular file")
+	}
+	Info("after")
+
+	var got []string
+	for _, e := range fallback.entries(t) {
+		got = append(got, e.Message)
+	}
+	want := []string{"primary output failed, switching to fallback output 1: device unavailable", "before", "after"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("fallback holds %q, want %q", got, want)
+	}
+}
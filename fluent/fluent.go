@@ -0,0 +1,304 @@
+// Package fluent sends log entries to Fluentd or Fluent Bit using the
+// forward protocol, MessagePack over TCP, instead of having them tail the
+// log file.
+//
+// It is kept out of the logger package like s3archive. Entries are
+// batched and sent as PackedForward messages, one per tag, and with
+// RequireAck set every message carries a chunk ID that the server must
+// acknowledge, which gives at-least-once delivery: a batch that was sent
+// but not acknowledged is sent again.
+//
+//	s, err := fluent.New(fluent.Config{Addr: "localhost:24224", Tag: "app.{level}", RequireAck: true})
+//	logger.AddEntryOutput("fluentd", s, logger.OutputConfig{})
+//	defer s.Close(context.Background())
+//
+// The sink receives the entries as logged, whatever the format of the log
+// file, and records the trace context of logger.InfoCtx and the other Ctx
+// functions as trace_id and span_id.
+package fluent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/73ddy-io/logger"
+)
+
+// Config describes the server entries are sent to.
+type Config struct {
+	// Addr is the address of the forward input, such as "localhost:24224".
+	Addr string
+
+	// Tag is the Fluentd tag of every entry, "logger" by default. It may
+	// contain {level}, replaced with debug, info, warn or error.
+	Tag string
+
+	// RequireAck asks the server to acknowledge every message, which is
+	// then resent until it is.
+	RequireAck bool
+
+	// MaxCount, MaxBytes and MaxWait limit a batch as in
+	// logger.BatchConfig. If all are zero, batches of up to 100 entries
+	// are sent at least once a second.
+	MaxCount int
+	MaxBytes int
+	MaxWait  time.Duration
+
+	// Retry controls reconnecting and resending after a failure;
+	// logger.DefaultRetryPolicy if nil.
+	Retry *logger.RetryPolicy
+
+	// Timeout bounds connecting, writing a message and waiting for its
+	// acknowledgment. The default is 10s.
+	Timeout time.Duration
+
+	// Dial opens connections, for example with TLS; a net.Dialer if nil.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Sink sends entries to a forward server. It implements
+// logger.EntryWriter for logger.AddEntryOutput, and entries can also be
+// added directly with Add.
+type Sink struct {
+	cfg  Config
+	tags [len(levelNames)]string
+	b    *logger.Batcher
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// levelNames are the names of the levels in tags and records, indexed by
+// level - logger.DEBUG.
+var levelNames = [logger.ERROR - logger.DEBUG + 1]string{"debug", "info", "warn", "error"}
+
+// expired is a deadline in the past. It is set on a connection when the
+// timeout, measured on the logger's clock, elapses, which makes the pending
+// write or read fail.
+var expired = time.Unix(1, 0)
+
+// New validates cfg and returns a Sink. The connection is opened when the
+// first batch is sent.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("fluent: address is required")
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = "logger"
+	}
+	if cfg.MaxCount <= 0 && cfg.MaxBytes <= 0 && cfg.MaxWait <= 0 {
+		cfg.MaxCount = 100
+		cfg.MaxWait = time.Second
+	}
+	if cfg.Retry == nil {
+		cfg.Retry = &logger.DefaultRetryPolicy
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Dial == nil {
+		cfg.Dial = (&net.Dialer{}).DialContext
+	}
+
+	s := &Sink{cfg: cfg}
+	for i, name := range levelNames {
+		s.tags[i] = strings.ReplaceAll(cfg.Tag, "{level}", name)
+	}
+	s.b = logger.NewBatcher(logger.BatchConfig{
+		MaxCount: cfg.MaxCount,
+		MaxBytes: cfg.MaxBytes,
+		MaxWait:  cfg.MaxWait,
+		Flush:    s.send,
+		Retry:    cfg.Retry,
+	})
+	return s, nil
+}
+
+// WriteEntry implements logger.EntryWriter, queueing e to be sent with the
+// next batch.
+func (s *Sink) WriteEntry(e logger.Entry) error {
+	return s.b.Add(e)
+}
+
+// Add queues e to be sent with the next batch.
+func (s *Sink) Add(e logger.Entry) error {
+	return s.b.Add(e)
+}
+
+// Close sends the pending entries, waiting until they are sent or ctx is
+// done, and closes the connection.
+func (s *Sink) Close(ctx context.Context) error {
+	err := s.b.Close(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// send delivers batch as one PackedForward message per tag. On failure the
+// connection is dropped so that the next attempt reconnects.
+func (s *Sink) send(batch []logger.Entry) error {
+	var (
+		tags   []string
+		events = make(map[string][]byte)
+		counts = make(map[string]int)
+	)
+	for i := range batch {
+		e := &batch[i]
+		tag := s.tags[levelIndex(e.Level)]
+		if _, ok := events[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		events[tag] = appendEvent(events[tag], e)
+		counts[tag]++
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		if err := s.sendMessage(tag, events[tag], counts[tag]); err != nil {
+			if s.conn != nil {
+				s.conn.Close()
+				s.conn = nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessage writes one PackedForward message and waits for its
+// acknowledgment if required. The caller must hold s.mu.
+func (s *Sink) sendMessage(tag string, events []byte, count int) error {
+	if s.conn == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		t := logger.CurrentClock().AfterFunc(s.cfg.Timeout, cancel)
+		conn, err := s.cfg.Dial(ctx, "tcp", s.cfg.Addr)
+		t.Stop()
+		cancel()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	var chunk string
+	option := 1
+	if s.cfg.RequireAck {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		option++
+	}
+
+	msg := appendArray(nil, 3)
+	msg = appendString(msg, tag)
+	msg = appendBin(msg, events)
+	msg = appendMap(msg, option)
+	msg = appendString(msg, "size")
+	msg = appendInt(msg, int64(count))
+	if chunk != "" {
+		msg = appendString(msg, "chunk")
+		msg = appendString(msg, chunk)
+	}
+
+	s.conn.SetDeadline(time.Time{})
+	conn := s.conn
+	t := logger.CurrentClock().AfterFunc(s.cfg.Timeout, func() {
+		conn.SetDeadline(expired)
+	})
+	defer t.Stop()
+
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	return s.readAck(chunk)
+}
+
+// readAck waits for the server to acknowledge chunk.
+func (s *Sink) readAck(chunk string) error {
+	var buf bytes.Buffer
+	p := make([]byte, 512)
+	for {
+		n, err := s.conn.Read(p)
+		buf.Write(p[:n])
+		if m, used, derr := decodeStringMap(buf.Bytes()); derr != nil {
+			return derr
+		} else if used > 0 {
+			if m["ack"] != chunk {
+				return fmt.Errorf("fluent: server acknowledged %q instead of %q", m["ack"], chunk)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fluent: waiting for acknowledgment: %w", err)
+		}
+	}
+}
+
+// appendEvent appends e to an entry stream as [time, record].
+func appendEvent(b []byte, e *logger.Entry) []byte {
+	n := 6
+	if e.ID != "" {
+		n++
+	}
+	if e.TraceID != "" {
+		n += 2
+	}
+	b = appendArray(b, 2)
+	b = appendEventTime(b, e.Time)
+	b = appendMap(b, n)
+	b = appendString(b, "level")
+	b = appendString(b, levelNames[levelIndex(e.Level)])
+	b = appendString(b, "message")
+	b = appendString(b, e.Message)
+	b = appendString(b, "pid")
+	b = appendInt(b, int64(e.PID))
+	b = appendString(b, "file")
+	b = appendString(b, e.File)
+	b = appendString(b, "line")
+	b = appendInt(b, int64(e.Line))
+	b = appendString(b, "func")
+	b = appendString(b, e.Func)
+	if e.ID != "" {
+		b = appendString(b, "id")
+		b = appendString(b, e.ID)
+	}
+	if e.TraceID != "" {
+		b = appendString(b, "trace_id")
+		b = appendString(b, e.TraceID)
+		b = appendString(b, "span_id")
+		b = appendString(b, e.SpanID)
+	}
+	return b
+}
+
+// levelIndex returns the index of l in levelNames, clamping unknown
+// levels to the nearest known one.
+func levelIndex(l logger.LogLevel) int {
+	if l < logger.DEBUG {
+		l = logger.DEBUG
+	}
+	if l > logger.ERROR {
+		l = logger.ERROR
+	}
+	return int(l - logger.DEBUG)
+}
@@ -0,0 +1,323 @@
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/73ddy-io/logger"
+)
+
+// readValue decodes one MessagePack value of the types the sink sends.
+// Maps are returned as map[string]interface{}, EventTime as time.Time.
+func readValue(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	readN := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	length := func(size int) (int, error) {
+		b, err := readN(size)
+		if err != nil {
+			return 0, err
+		}
+		switch size {
+		case 1:
+			return int(b[0]), nil
+		case 2:
+			return int(binary.BigEndian.Uint16(b)), nil
+		}
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+	var n int
+	switch {
+	case c < 0x80:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		b, err := readN(int(c & 0x1f))
+		return string(b), err
+	case c == 0xd9, c == 0xda, c == 0xdb:
+		if n, err = length(1 << (c - 0xd9)); err != nil {
+			return nil, err
+		}
+		b, err := readN(n)
+		return string(b), err
+	case c == 0xc4, c == 0xc5, c == 0xc6:
+		if n, err = length(1 << (c - 0xc4)); err != nil {
+			return nil, err
+		}
+		return readN(n)
+	case c == 0xd3:
+		b, err := readN(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(binary.BigEndian.Uint64(b)), nil
+	case c == 0xd7:
+		b, err := readN(9)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != 0 {
+			return nil, fmt.Errorf("extension type %d", b[0])
+		}
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:])), int64(binary.BigEndian.Uint32(b[5:]))), nil
+	case c&0xf0 == 0x90, c == 0xdc, c == 0xdd:
+		if n = int(c & 0x0f); c >= 0xdc {
+			if n, err = length(2 << (c - 0xdc)); err != nil {
+				return nil, err
+			}
+		}
+		a := make([]interface{}, n)
+		for i := range a {
+			if a[i], err = readValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case c&0xf0 == 0x80, c == 0xde, c == 0xdf:
+		if n = int(c & 0x0f); c >= 0xde {
+			if n, err = length(2 << (c - 0xde)); err != nil {
+				return nil, err
+			}
+		}
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			k, err := readValue(r)
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(k)], err = readValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unexpected type 0x%02x", c)
+}
+
+// event is an entry received by forwardServer.
+type event struct {
+	tag    string
+	time   time.Time
+	record map[string]interface{}
+}
+
+// forwardServer is a minimal forward input. It validates every
+// PackedForward message and acknowledges its chunk, except that the
+// first dropAcks messages are answered by closing the connection.
+type forwardServer struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu       sync.Mutex
+	dropAcks int
+	conns    int
+	events   []event
+	chunks   map[string]int
+}
+
+func newForwardServer(t *testing.T, dropAcks int) *forwardServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &forwardServer{t: t, ln: ln, dropAcks: dropAcks, chunks: make(map[string]int)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *forwardServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		v, err := readValue(r)
+		if err != nil {
+			return
+		}
+		chunk, err := s.receive(v)
+		if err != nil {
+			s.t.Errorf("invalid message: %v", err)
+			return
+		}
+		s.mu.Lock()
+		drop := s.dropAcks > 0
+		if drop {
+			s.dropAcks--
+		}
+		s.mu.Unlock()
+		if drop {
+			return
+		}
+		if chunk != "" {
+			conn.Write(appendString(appendString(appendMap(nil, 1), "ack"), chunk))
+		}
+	}
+}
+
+// receive validates a PackedForward message, records its events and
+// returns its chunk.
+func (s *forwardServer) receive(v interface{}) (string, error) {
+	msg, ok := v.([]interface{})
+	if !ok || len(msg) != 3 {
+		return "", fmt.Errorf("message is %#v, want [tag, entries, option]", v)
+	}
+	tag, ok := msg[0].(string)
+	entries, eok := msg[1].([]byte)
+	option, ook := msg[2].(map[string]interface{})
+	if !ok || !eok || !ook {
+		return "", fmt.Errorf("message is %#v", v)
+	}
+
+	var events []event
+	r := bufio.NewReader(bytes.NewReader(entries))
+	for {
+		v, err := readValue(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		pair, ok := v.([]interface{})
+		if !ok || len(pair) != 2 {
+			return "", fmt.Errorf("entry is %#v, want [time, record]", v)
+		}
+		ts, tok := pair[0].(time.Time)
+		record, rok := pair[1].(map[string]interface{})
+		if !tok || !rok {
+			return "", fmt.Errorf("entry is %#v", v)
+		}
+		events = append(events, event{tag: tag, time: ts, record: record})
+	}
+	if size, _ := option["size"].(int64); int(size) != len(events) {
+		return "", fmt.Errorf("size option %v, but %d entries", option["size"], len(events))
+	}
+	chunk, _ := option["chunk"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	if chunk != "" {
+		s.chunks[chunk]++
+	}
+	return chunk, nil
+}
+
+func newTestSink(t *testing.T, addr string) *Sink {
+	t.Helper()
+	s, err := New(Config{
+		Addr:       addr,
+		Tag:        "app.{level}",
+		RequireAck: true,
+		MaxCount:   10,
+		MaxWait:    time.Hour,
+		Retry:      &logger.RetryPolicy{InitialDelay: time.Millisecond, MaxAttempts: 5},
+		Timeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestForwardAcknowledged(t *testing.T) {
+	srv := newForwardServer(t, 0)
+	sink := newTestSink(t, srv.ln.Addr().String())
+
+	if err := logger.InitLogger(filepath.Join(t.TempDir(), "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	if err := logger.AddEntryOutput("fluentd", sink, logger.OutputConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const traceID, spanID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	logger.InfoCtx(logger.ContextWithTrace(context.Background(), traceID, spanID), "order %d shipped", 7)
+	logger.Error("payment failed")
+	logger.RemoveOutput("fluentd")
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.events) != 2 {
+		t.Fatalf("received %d events, want 2", len(srv.events))
+	}
+	sort.Slice(srv.events, func(i, j int) bool { return srv.events[i].tag > srv.events[j].tag })
+	info, failure := srv.events[0], srv.events[1]
+	if info.tag != "app.info" || failure.tag != "app.error" {
+		t.Errorf("tags = %q, %q", info.tag, failure.tag)
+	}
+	for key, want := range map[string]interface{}{
+		"level":    "info",
+		"message":  "order 7 shipped",
+		"file":     "fluent_test.go",
+		"func":     "TestForwardAcknowledged",
+		"trace_id": traceID,
+		"span_id":  spanID,
+	} {
+		if got := info.record[key]; got != want {
+			t.Errorf("record[%q] = %#v, want %#v", key, got, want)
+		}
+	}
+	if _, ok := failure.record["trace_id"]; ok || failure.record["message"] != "payment failed" {
+		t.Errorf("error record = %v", failure.record)
+	}
+	if info.time.IsZero() || time.Since(info.time) > time.Minute {
+		t.Errorf("event time = %v", info.time)
+	}
+	if len(srv.chunks) != 2 {
+		t.Errorf("%d chunks acknowledged, want one per tag", len(srv.chunks))
+	}
+}
+
+func TestForwardResendsUnacknowledged(t *testing.T) {
+	srv := newForwardServer(t, 1)
+	sink := newTestSink(t, srv.ln.Addr().String())
+
+	for i := 0; i < 3; i++ {
+		sink.Add(logger.Entry{Time: time.Now(), Level: logger.WARN, Message: fmt.Sprint("entry ", i)})
+	}
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.conns != 2 {
+		t.Errorf("%d connections, want a reconnect after the lost acknowledgment", srv.conns)
+	}
+	if len(srv.events) != 6 {
+		t.Errorf("received %d events, want the batch of 3 twice", len(srv.events))
+	}
+}
@@ -0,0 +1,210 @@
+package fluent
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// The MessagePack encoding below covers the types the forward protocol
+// needs; see https://github.com/msgpack/msgpack/blob/master/spec.md.
+
+// appendArray appends the header of an array of n elements.
+func appendArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+}
+
+// appendMap appends the header of a map of n pairs.
+func appendMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+// appendString appends s as a str.
+func appendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendBin appends p as a bin.
+func appendBin(b []byte, p []byte) []byte {
+	switch n := len(p); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, p...)
+}
+
+// appendInt appends n as an int.
+func appendInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128:
+		return append(b, byte(n))
+	case n >= -32 && n < 0:
+		return append(b, byte(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+}
+
+// appendEventTime appends t as an EventTime, the extension type 0 the
+// forward protocol uses for timestamps with nanoseconds.
+func appendEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+var errMsgpack = errors.New("fluent: malformed response")
+
+// decodeStringMap decodes a map whose values are strings, such as the
+// {"ack": chunk} response of a server, from the start of p. It returns
+// the map and the number of bytes used, or n == 0 if p is incomplete.
+// Values of other types are skipped.
+func decodeStringMap(p []byte) (m map[string]string, n int, err error) {
+	d := decoder{p: p}
+	size, ok := d.mapHeader()
+	if !ok {
+		return nil, 0, d.err
+	}
+	m = make(map[string]string, size)
+	for i := 0; i < size; i++ {
+		k, kok := d.str()
+		v, vok := d.str()
+		switch {
+		case d.err != nil:
+			return nil, 0, d.err
+		case d.incomplete:
+			return nil, 0, nil
+		case !kok:
+			return nil, 0, errMsgpack
+		case vok:
+			m[k] = v
+		}
+	}
+	if d.incomplete {
+		return nil, 0, nil
+	}
+	return m, d.off, nil
+}
+
+// decoder reads the few MessagePack types found in server responses.
+type decoder struct {
+	p          []byte
+	off        int
+	incomplete bool
+	err        error
+}
+
+// take returns the next n bytes.
+func (d *decoder) take(n int) ([]byte, bool) {
+	if len(d.p)-d.off < n {
+		d.incomplete = true
+		return nil, false
+	}
+	b := d.p[d.off : d.off+n]
+	d.off += n
+	return b, true
+}
+
+// length reads a big-endian length of size bytes.
+func (d *decoder) length(size int) (int, bool) {
+	b, ok := d.take(size)
+	if !ok {
+		return 0, false
+	}
+	switch size {
+	case 1:
+		return int(b[0]), true
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), true
+	}
+	return int(binary.BigEndian.Uint32(b)), true
+}
+
+// mapHeader reads the header of a map.
+func (d *decoder) mapHeader() (int, bool) {
+	b, ok := d.take(1)
+	if !ok {
+		return 0, false
+	}
+	switch c := b[0]; {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), true
+	case c == 0xde:
+		return d.length(2)
+	case c == 0xdf:
+		return d.length(4)
+	}
+	d.err = errMsgpack
+	return 0, false
+}
+
+// str reads a str. Any other value is skipped and reported as !ok with
+// no error.
+func (d *decoder) str() (string, bool) {
+	b, ok := d.take(1)
+	if !ok {
+		return "", false
+	}
+	var n int
+	switch c := b[0]; {
+	case c&0xe0 == 0xa0:
+		n = int(c & 0x1f)
+	case c == 0xd9:
+		n, ok = d.length(1)
+	case c == 0xda:
+		n, ok = d.length(2)
+	case c == 0xdb:
+		n, ok = d.length(4)
+	default:
+		d.skip(c)
+		return "", false
+	}
+	if !ok {
+		return "", false
+	}
+	s, ok := d.take(n)
+	return string(s), ok
+}
+
+// skip skips a scalar value starting with c. Containers are rejected.
+func (d *decoder) skip(c byte) {
+	switch {
+	case c < 0x80, c >= 0xe0, c == 0xc0, c == 0xc2, c == 0xc3:
+	case c == 0xcc, c == 0xd0:
+		d.take(1)
+	case c == 0xcd, c == 0xd1:
+		d.take(2)
+	case c == 0xce, c == 0xd2, c == 0xca:
+		d.take(4)
+	case c == 0xcf, c == 0xd3, c == 0xcb:
+		d.take(8)
+	default:
+		d.err = errMsgpack
+	}
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Follow settings.
+const (
+	followPollInterval = 250 * time.Millisecond
+	followBufferSize   = 1024
+)
+
+var followDropped atomic.Uint64
+
+// Follow streams the entries appended to the log file at path, like
+// "tail -F".
+//
+// With fromEnd set, only entries written after the call are delivered;
+// otherwise the file is read from the beginning. The file is polled for new
+// data, and when it is rotated or truncated the path is reopened, after the
+// remainder of the old file has been read. Lines that do not parse as
+// entries are skipped.
+//
+// Entries are delivered on a buffered channel. When the consumer falls
+// behind and the buffer is full, entries are dropped and counted in
+// Stats.FollowDropped rather than accumulating in memory. The channel is
+// closed when ctx is done.
+func Follow(ctx context.Context, path string, fromEnd bool) (<-chan Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset int64
+	if fromEnd {
+		if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	ch := make(chan Entry, followBufferSize)
+	fl := &follower{path: path, file: f, r: bufio.NewReader(f), offset: offset, ch: ch}
+	go fl.run(ctx)
+	return ch, nil
+}
+
+// follower holds the state of a Follow call.
+type follower struct {
+	path    string
+	file    *os.File
+	r       *bufio.Reader
+	offset  int64
+	partial string
+	ch      chan Entry
+}
+
+// run polls the file until ctx is done.
+func (fl *follower) run(ctx context.Context) {
+	defer close(fl.ch)
+	defer func() { fl.file.Close() }()
+
+	for {
+		fl.drain()
+
+		t := newClockTimer(followPollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+		}
+
+		fl.checkFile()
+	}
+}
+
+// drain reads and delivers every complete line available in the file.
+// A trailing incomplete line is kept until the rest of it is written.
+func (fl *follower) drain() {
+	for {
+		line, err := fl.r.ReadString('\n')
+		fl.offset += int64(len(line))
+		if err != nil {
+			fl.partial += line
+			return
+		}
+
+		line = fl.partial + line
+		fl.partial = ""
+		if e, err := ParseLine(line); err == nil {
+			select {
+			case fl.ch <- e:
+			default:
+				followDropped.Add(1)
+			}
+		}
+	}
+}
+
+// checkFile reopens the path if the file was rotated, or rewinds if it
+// was truncated.
+func (fl *follower) checkFile() {
+	current, err := fl.file.Stat()
+	if err != nil {
+		return
+	}
+
+	info, err := os.Stat(fl.path)
+	if err != nil {
+		return
+	}
+
+	if !os.SameFile(current, info) {
+		fl.drain()
+		f, err := os.Open(fl.path)
+		if err != nil {
+			return
+		}
+		fl.file.Close()
+		fl.reset(f)
+		return
+	}
+
+	if info.Size() < fl.offset {
+		if _, err := fl.file.Seek(0, io.SeekStart); err == nil {
+			fl.reset(fl.file)
+		}
+	}
+}
+
+// reset starts reading f from its current position.
+func (fl *follower) reset(f *os.File) {
+	fl.file = f
+	fl.r.Reset(f)
+	fl.offset = 0
+	fl.partial = ""
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFollowAcrossRotation(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 8, 1, 9, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+	path := filepath.Join(dir, "app.log")
+	Info("before follow")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := Follow(ctx, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tick lets the follower poll once, waiting until it is idle first.
+	tick := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for clock.Waiters() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("follower is not polling")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(followPollInterval)
+	}
+
+	const total = 200
+	for i := 0; i < total; i++ {
+		Info("line %d", i)
+		switch i {
+		case 40, 120:
+			tick()
+		case 80, 160:
+			if _, err := Rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var got []string
+	timeout := time.After(10 * time.Second)
+	for len(got) < total {
+		select {
+		case e := <-ch:
+			got = append(got, e.Message)
+		case <-time.After(10 * time.Millisecond):
+			tick()
+		case <-timeout:
+			t.Fatalf("received %d of %d lines", len(got), total)
+		}
+	}
+	for i, msg := range got {
+		if want := "line " + strconv.Itoa(i); msg != want {
+			t.Fatalf("line %d = %q, want %q", i, msg, want)
+		}
+	}
+
+	// Nothing else arrives: no line was delivered twice.
+	tick()
+	tick()
+	select {
+	case e := <-ch:
+		t.Errorf("extra entry %q", e.Message)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
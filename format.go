@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Entry is a single log record, as passed to a Formatter.
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	PID     int
+	File    string
+	Line    int
+	Func    string
+	Message string
+
+	// ID uniquely identifies the entry when event IDs are enabled with
+	// WithEventIDs, and is empty otherwise.
+	ID string
+
+	// TraceID and SpanID are the trace context of entries logged with
+	// InfoCtx and the other Ctx functions; see ContextWithTrace.
+	TraceID string
+	SpanID  string
+
+	// request identifies the request of entries logged with the Ctx
+	// functions for the trigger buffer; see WithTriggerBuffer.
+	request string
+
+	// Version is the build read back by ParseLine from the " ver=" token
+	// of TextFormatter. It is empty for entries being logged, whose build
+	// comes from EnableBuildInfo or SetVersion.
+	Version string
+}
+
+// Formatter renders an entry into a single log line, without the trailing
+// newline.
+type Formatter interface {
+	Format(e *Entry) string
+}
+
+// HeaderFormatter is implemented by formatters whose output starts with a
+// header, such as the column names of CSVFormatter. The header is written
+// whenever the logger starts a new, empty file.
+type HeaderFormatter interface {
+	Formatter
+	Header() string
+}
+
+// writeFileHeader writes the header of the configured formatter to f if
+// f is empty. The caller must hold mu.
+func writeFileHeader(f *os.File) {
+	hf, ok := cfg.formatter.(HeaderFormatter)
+	if !ok || cfg.binary {
+		return
+	}
+	header := hf.Header()
+	if header == "" {
+		return
+	}
+	if info, err := f.Stat(); err != nil || info.Size() > 0 {
+		return
+	}
+	if _, err := f.WriteString(header + "\n"); err != nil {
+		reportError(fmt.Errorf("failed to write log file header: %w", err))
+	}
+}
+
+// TextFormatter renders entries in the default layout:
+//
+//	2006-01-02 15:04:05 [INFO] (1234)main.go:12 main - message
+//
+// Entries with a trace context end with " trace=... span=..." tokens and
+// entries with an ID with an " id=..." token.
+type TextFormatter struct {
+	// Version appends a " ver=..." token identifying the build to every
+	// line once EnableBuildInfo or SetVersion has provided one.
+	Version bool
+}
+
+// Format implements Formatter.
+func (f TextFormatter) Format(e *Entry) string {
+	line := fmt.Sprintf("%s [%s] (%d)%s:%d %s - %s",
+		formatTimestamp(e.Time),
+		levelName(e.Level),
+		e.PID,
+		e.File,
+		e.Line,
+		e.Func,
+		e.Message,
+	)
+	if e.TraceID != "" {
+		line += " trace=" + e.TraceID + " span=" + e.SpanID
+	}
+	if e.ID != "" {
+		line += " id=" + e.ID
+	}
+	if f.Version {
+		v := e.Version
+		if v == "" {
+			v = versionToken()
+		}
+		if v != "" {
+			line += " ver=" + v
+		}
+	}
+	return line
+}
+
+// levelName returns the token used for level in the text format.
+func levelName(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERR"
+	}
+	return ""
+}
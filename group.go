@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxGroupEntries is the number of entries a Group holds; further entries
+// are dropped.
+const maxGroupEntries = 1000
+
+// Group collects entries that are written to the log as one contiguous
+// block, so that entries logged concurrently by other goroutines cannot
+// end up between them. Entries get their time and caller when they are
+// added, not when the group is committed.
+//
+// A Group is safe for use by multiple goroutines. A group that is neither
+// committed nor discarded is written by Close, preceded by a warning.
+type Group struct {
+	mu      sync.Mutex
+	entries []Entry
+	dropped int
+	done    bool
+}
+
+// openGroups are the groups started with BeginGroup that have not been
+// committed or discarded yet. It is guarded by mu.
+var openGroups = make(map[*Group]struct{})
+
+// BeginGroup starts a new group of entries.
+func BeginGroup() *Group {
+	g := &Group{}
+	mu.Lock()
+	openGroups[g] = struct{}{}
+	mu.Unlock()
+	return g
+}
+
+// Debug adds a DEBUG entry to the group; see the package-level Debug.
+func (g *Group) Debug(format string, args ...interface{}) {
+	g.add(DEBUG, format, args)
+}
+
+// Info adds an INFO entry to the group; see the package-level Info.
+func (g *Group) Info(format string, args ...interface{}) {
+	g.add(INFO, format, args)
+}
+
+// Warn adds a WARN entry to the group; see the package-level Warn.
+func (g *Group) Warn(format string, args ...interface{}) {
+	g.add(WARN, format, args)
+}
+
+// Error adds an ERROR entry to the group; see the package-level Error.
+func (g *Group) Error(format string, args ...interface{}) {
+	g.add(ERROR, format, args)
+}
+
+// add adds an entry at level attributed to the caller of the method that
+// called add. Once the group is committed or discarded, the entry is
+// written on its own instead.
+func (g *Group) add(level LogLevel, format string, args []interface{}) {
+	if !enabled(level, 2) {
+		return
+	}
+	e := newEntry(clockNow(), level, 2, fmt.Sprintf(format, args...))
+
+	g.mu.Lock()
+	if g.done {
+		g.mu.Unlock()
+		write(e)
+		return
+	}
+	if len(g.entries) < maxGroupEntries {
+		g.entries = append(g.entries, e)
+	} else {
+		g.dropped++
+	}
+	g.mu.Unlock()
+}
+
+// Commit writes the entries of the group as one block. If the group
+// exceeded its limit of 1000 entries, it ends with a warning giving the
+// number of entries dropped. Calling Commit again has no effect.
+func (g *Group) Commit() {
+	es := g.take(clockNow(), 1)
+
+	mu.Lock()
+	delete(openGroups, g)
+	mu.Unlock()
+
+	if len(es) > 0 {
+		writeEntries(es)
+	}
+}
+
+// Discard drops the entries of the group without writing them.
+func (g *Group) Discard() {
+	g.take(clockNow(), 1)
+
+	mu.Lock()
+	delete(openGroups, g)
+	mu.Unlock()
+}
+
+// take marks the group as done and returns its entries, followed by a
+// warning logged at now and attributed to the caller skip frames above
+// the caller of take if entries were dropped.
+func (g *Group) take(now time.Time, skip int) []Entry {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.done {
+		return nil
+	}
+	g.done = true
+	es := g.entries
+	if g.dropped > 0 {
+		msg := fmt.Sprintf("group exceeded %d entries, dropped %d", maxGroupEntries, g.dropped)
+		es = append(es, newEntry(now, WARN, skip+1, msg))
+	}
+	g.entries = nil
+	return es
+}
+
+// flushOpenGroups writes the groups that were never committed or
+// discarded. The caller must hold mu and the logger must be open.
+func flushOpenGroups() {
+	for g := range openGroups {
+		delete(openGroups, g)
+		es := g.take(clockNow(), 1)
+		if len(es) == 0 {
+			continue
+		}
+		logInternal(WARN, fmt.Sprintf("writing uncommitted group of %d entries at Close", len(es)))
+		writeLocked(es)
+	}
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGroupContiguousUnderConcurrency(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+
+	const groups, perGroup, noisy = 8, 20, 4
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	var noise sync.WaitGroup
+	for n := 0; n < noisy; n++ {
+		noise.Add(1)
+		go func(n int) {
+			defer noise.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				Info("noise %d %d", n, i)
+			}
+		}(n)
+	}
+	for g := 0; g < groups; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			grp := BeginGroup()
+			for i := 0; i < perGroup; i++ {
+				// Filling goes on concurrently with the noise, only the
+				// commit writes.
+				grp.Info("group %d step %d", g, i)
+			}
+			grp.Commit()
+		}(g)
+	}
+	wg.Wait()
+	close(stop)
+	noise.Wait()
+
+	msgs := messages(t, filepath.Join(dir, "app.log"))
+	for g := 0; g < groups; g++ {
+		first := fmt.Sprintf("group %d step 0", g)
+		start := -1
+		for i, m := range msgs {
+			if m == first {
+				start = i
+				break
+			}
+		}
+		if start < 0 || start+perGroup > len(msgs) {
+			t.Fatalf("group %d not found", g)
+		}
+		for i := 0; i < perGroup; i++ {
+			if want := fmt.Sprintf("group %d step %d", g, i); msgs[start+i] != want {
+				t.Errorf("group %d: line %d after its start is %q, want %q", g, i, msgs[start+i], want)
+				break
+			}
+		}
+	}
+	count := 0
+	for _, m := range msgs {
+		if strings.HasPrefix(m, "group ") {
+			count++
+		}
+	}
+	if count != groups*perGroup {
+		t.Errorf("wrote %d group entries, want %d", count, groups*perGroup)
+	}
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDiskFull is reported to the error handler when the disk guard stops
+// writing because free space fell below its hard floor.
+var ErrDiskFull = errors.New("logger: free disk space below hard floor, writing suspended")
+
+// DiskGuard protects the log volume from filling up. It is enabled with
+// WithDiskGuard.
+//
+// Free space is checked every CheckInterval and after every CheckBytes
+// bytes written. When it falls below MinFree, the oldest rotated files of
+// the active log are deleted until enough space is free or none are left.
+// If free space is still below HardFloor, entries are dropped and counted
+// in Stats.DiskDropped until space recovers. Each transition is logged and
+// reported once, not on every entry.
+type DiskGuard struct {
+	MinFree       uint64
+	HardFloor     uint64
+	CheckInterval time.Duration
+	CheckBytes    int64
+}
+
+// WithDiskGuard enables the disk space guard. A zero CheckInterval
+// defaults to 10 seconds and a zero CheckBytes to 1 MiB. Free space is
+// measured on Linux, macOS, FreeBSD and DragonFly; elsewhere the guard has
+// no effect.
+func WithDiskGuard(g DiskGuard) Option {
+	return func(c *config) {
+		if g.CheckInterval <= 0 {
+			g.CheckInterval = 10 * time.Second
+		}
+		if g.CheckBytes <= 0 {
+			g.CheckBytes = 1 << 20
+		}
+		c.diskGuard = &g
+	}
+}
+
+var (
+	// statfs returns the space available to unprivileged users on the
+	// file system holding path. It is a variable so tests can simulate a
+	// filling disk.
+	statfs = diskFree
+
+	diskDropped     atomic.Uint64
+	bytesSinceCheck int64
+	lastDiskCheck   time.Time
+	diskSuspended   bool
+)
+
+// guardAllows reports whether an entry logged at now may be written, and
+// returns an error to report when writing has just been suspended. The
+// caller must hold mu.
+func guardAllows(now time.Time) (bool, error) {
+	g := cfg.diskGuard
+	if g == nil || logFile == nil {
+		return true, nil
+	}
+	if bytesSinceCheck < g.CheckBytes && now.Sub(lastDiskCheck) < g.CheckInterval {
+		return !diskSuspended, nil
+	}
+	bytesSinceCheck = 0
+	lastDiskCheck = now
+
+	dir := filepath.Dir(logPath)
+	free, err := statfs(dir)
+	if err != nil {
+		return !diskSuspended, nil
+	}
+
+	if free < g.MinFree {
+		free = purgeBackups(dir, free, g.MinFree)
+	}
+
+	switch {
+	case free < g.HardFloor && !diskSuspended:
+		logInternal(ERROR, fmt.Sprintf("only %d bytes free on log volume, suspending logging", free))
+		diskSuspended = true
+		return false, ErrDiskFull
+	case free >= g.HardFloor && diskSuspended:
+		diskSuspended = false
+		logInternal(WARN, fmt.Sprintf("%d bytes free on log volume, resuming logging after dropping %d entries", free, diskDropped.Load()))
+	}
+	return !diskSuspended, nil
+}
+
+// purgeBackups deletes the oldest backups of the active log, including the
+// files of earlier dates with a date layout, until at least want bytes are
+// free in dir, and returns the resulting free space.
+func purgeBackups(dir string, free, want uint64) uint64 {
+	backups, err := retainedFiles()
+	if err != nil {
+		return free
+	}
+
+	removed := 0
+	for _, b := range backups {
+		if free >= want {
+			break
+		}
+		if os.Remove(b.path) != nil {
+			continue
+		}
+		removed++
+		if free, err = statfs(dir); err != nil {
+			break
+		}
+	}
+	if removed > 0 {
+		logInternal(WARN, fmt.Sprintf("low disk space, removed %d rotated log files", removed))
+	}
+	return free
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux
+
+package logger
+
+import "errors"
+
+// diskFree is not supported on this platform, which disables the disk
+// guard.
+func diskFree(path string) (uint64, error) {
+	return 0, errors.New("logger: free disk space not available on this platform")
+}
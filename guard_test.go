@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDisk simulates a volume of the given capacity holding only the
+// files of one directory.
+type fakeDisk struct {
+	dir      string
+	capacity atomic.Uint64
+}
+
+func (d *fakeDisk) statfs(string) (uint64, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return 0, err
+	}
+	var used uint64
+	for _, de := range entries {
+		if info, err := de.Info(); err == nil {
+			used += uint64(info.Size())
+		}
+	}
+	if c := d.capacity.Load(); c > used {
+		return c - used, nil
+	}
+	return 0, nil
+}
+
+// useFakeDisk makes the disk guard measure free space on a fakeDisk.
+func useFakeDisk(t *testing.T, dir string, capacity uint64) *fakeDisk {
+	d := &fakeDisk{dir: dir}
+	d.capacity.Store(capacity)
+	mu.Lock()
+	statfs = d.statfs
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		statfs = diskFree
+		mu.Unlock()
+	})
+	return d
+}
+
+func TestDiskGuardShrinkingSpace(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local))
+	dir := t.TempDir()
+	disk := useFakeDisk(t, dir, 1<<20)
+	path := filepath.Join(dir, "app.log")
+	if err := InitLogger(path, WithDiskGuard(DiskGuard{MinFree: 3000, HardFloor: 1000, CheckBytes: 1})); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	var full atomic.Int32
+	SetErrorHandler(func(err error) {
+		if errors.Is(err, ErrDiskFull) {
+			full.Add(1)
+		}
+	})
+	defer SetErrorHandler(nil)
+
+	msg := strings.Repeat("x", 1000)
+	var backups []string
+	for i := 0; i < 3; i++ {
+		Info("%s", msg)
+		clock.Advance(time.Second)
+		backup, err := Rotate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups = append(backups, backup)
+	}
+
+	// Free space drops below MinFree: the oldest backups are pruned until
+	// it is back above it.
+	free, _ := disk.statfs(dir)
+	disk.capacity.Store(disk.capacity.Load() - free + 2500)
+	Info("after pruning")
+	if _, err := os.Stat(backups[0]); !os.IsNotExist(err) {
+		t.Errorf("oldest backup was kept: %v", err)
+	}
+	if _, err := os.Stat(backups[2]); err != nil {
+		t.Errorf("newest backup was pruned: %v", err)
+	}
+
+	// Free space drops below HardFloor with nothing left to prune that
+	// helps: writing is suspended.
+	for _, b := range backups[1:] {
+		os.Remove(b)
+	}
+	free, _ = disk.statfs(dir)
+	disk.capacity.Store(disk.capacity.Load() - free + 500)
+	dropped := CurrentStats().DiskDropped
+	Info("dropped 1")
+	Info("dropped 2")
+	if n := CurrentStats().DiskDropped - dropped; n != 2 {
+		t.Errorf("dropped %d entries, want 2", n)
+	}
+	if n := full.Load(); n != 1 {
+		t.Errorf("ErrDiskFull reported %d times, want once", n)
+	}
+
+	// Space recovers, noticed at the next periodic check since nothing is
+	// written meanwhile.
+	disk.capacity.Store(1 << 20)
+	clock.Advance(10 * time.Second)
+	Info("resumed")
+
+	got := messages(t, path)
+	want := []string{
+		"low disk space, removed 1 rotated log files",
+		"after pruning",
+		"only 500 bytes free on log volume, suspending logging",
+	}
+	for _, w := range want {
+		if !containsString(got, w) {
+			t.Errorf("log is missing %q: %q", w, got)
+		}
+	}
+	last := got[len(got)-2:]
+	if !strings.HasSuffix(last[0], fmt.Sprintf("resuming logging after dropping %d entries", CurrentStats().DiskDropped)) || last[1] != "resumed" {
+		t.Errorf("log ends with %q, want the resume notice and the entry", last)
+	}
+	for _, m := range got {
+		if strings.HasPrefix(m, "dropped") {
+			t.Errorf("entry %q was written while suspended", m)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
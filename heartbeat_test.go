@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatSkipsTicksWhileClosed(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC))
+	dir := initTestLogger(t, "app.log")
+	path := filepath.Join(dir, "app.log")
+
+	stop := StartHeartbeat(time.Minute, nil)
+	defer stop()
+
+	tick := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for clock.Waiters() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("heartbeat is not waiting for its next tick")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Minute)
+	}
+
+	tick()
+	Close()
+	before := CurrentStats().Dropped
+	tick()
+	tick()
+	if d := CurrentStats().Dropped; d != before {
+		t.Errorf("heartbeat counted %d entries as dropped while closed", d-before)
+	}
+
+	if err := InitLogger(path); err != nil {
+		t.Fatal(err)
+	}
+	tick()
+	tick()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(messages(t, path)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got heartbeats %q, want one before Close and one after InitLogger", messages(t, path))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// initTestLogger initializes the logger with a file named name in a
+// temporary directory and closes it when the test ends. It returns the
+// directory.
+func initTestLogger(t testing.TB, name string, opts ...Option) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := InitLogger(dir+"/"+name, opts...); err != nil {
+		t.Fatalf("InitLogger: %v", err)
+	}
+	t.Cleanup(func() {
+		Close()
+		SetLevel(DEBUG)
+	})
+	return dir
+}
+
+// readLines returns the lines of the file at path.
+func readLines(t testing.TB, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// readEntries parses the entries of the log file at path.
+func readEntries(t testing.TB, path string) []Entry {
+	t.Helper()
+	var es []Entry
+	for _, line := range readLines(t, path) {
+		e, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+		es = append(es, e)
+	}
+	return es
+}
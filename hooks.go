@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+)
+
+// rotation describes a completed rotation for the rotate hooks.
+type rotation struct {
+	oldPath string
+	newPath string
+}
+
+var (
+	hooksMu          sync.Mutex
+	rotateHooks      []func(oldPath, newPath string)
+	pendingRotations []rotation
+	hooksRunning     bool
+)
+
+// OnRotate registers fn to be called after every rotation with the path
+// the old file was moved to and the path of the new active file.
+//
+// Callbacks run asynchronously on a separate goroutine once the old file
+// is closed, so they may read, compress or move it. They are called in
+// registration order, and rotations are processed one at a time in the
+// order they happened. A panicking callback is recovered and reported to
+// the error handler.
+func OnRotate(fn func(oldPath, newPath string)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	rotateHooks = append(rotateHooks, fn)
+}
+
+// notifyRotate schedules the rotate hooks for a completed rotation. It
+// never blocks, so it may be called with mu held.
+func notifyRotate(oldPath, newPath string) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	if len(rotateHooks) == 0 {
+		return
+	}
+	pendingRotations = append(pendingRotations, rotation{oldPath: oldPath, newPath: newPath})
+	if !hooksRunning {
+		hooksRunning = true
+		go runRotateHooks()
+	}
+}
+
+// runRotateHooks calls the hooks for every pending rotation and exits
+// when the queue is empty.
+func runRotateHooks() {
+	for {
+		hooksMu.Lock()
+		if len(pendingRotations) == 0 {
+			hooksRunning = false
+			hooksMu.Unlock()
+			return
+		}
+		r := pendingRotations[0]
+		pendingRotations = pendingRotations[1:]
+		hooks := rotateHooks
+		hooksMu.Unlock()
+
+		for _, fn := range hooks {
+			callRotateHook(fn, r)
+		}
+	}
+}
+
+// callRotateHook calls fn, reporting a panic to the error handler.
+func callRotateHook(fn func(oldPath, newPath string), r rotation) {
+	defer func() {
+		if p := recover(); p != nil {
+			reportError(fmt.Errorf("logger: rotate hook panicked: %v", p))
+		}
+	}()
+	fn(r.oldPath, r.newPath)
+}
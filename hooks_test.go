@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useRotateHooks clears the registered rotate hooks for the duration of
+// the test.
+func useRotateHooks(t *testing.T) {
+	hooksMu.Lock()
+	saved := rotateHooks
+	rotateHooks = nil
+	hooksMu.Unlock()
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		rotateHooks = saved
+		hooksMu.Unlock()
+	})
+}
+
+// gzipFile compresses path to path + ".gz" and removes path.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// TestRotateHooksRunInOrder checks that the hooks of each rotation run in
+// registration order once the old file is complete, so that a later hook
+// sees the result of a compressing one, and that a panicking hook does not
+// stop the others.
+func TestRotateHooksRunInOrder(t *testing.T) {
+	initTestLogger(t, "app.log")
+	useRotateHooks(t)
+
+	var panics sync.WaitGroup
+	SetErrorHandler(func(err error) {
+		if strings.Contains(err.Error(), "rotate hook panicked: boom") {
+			panics.Done()
+		}
+	})
+	defer SetErrorHandler(nil)
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(s string) {
+		mu.Lock()
+		calls = append(calls, s)
+		mu.Unlock()
+	}
+	done := make(chan struct{}, 2)
+	OnRotate(func(oldPath, newPath string) {
+		if err := gzipFile(oldPath); err != nil {
+			t.Errorf("compressing %s: %v", oldPath, err)
+		}
+		record("compress")
+	})
+	OnRotate(func(oldPath, newPath string) {
+		panic("boom")
+	})
+	OnRotate(func(oldPath, newPath string) {
+		defer func() { done <- struct{}{} }()
+		f, err := os.Open(oldPath + ".gz")
+		if err != nil {
+			t.Errorf("compressed backup missing: %v", err)
+			return
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Errorf("reading %s.gz: %v", oldPath, err)
+			return
+		}
+		data, err := io.ReadAll(gz)
+		if err != nil {
+			t.Errorf("reading %s.gz: %v", oldPath, err)
+		}
+		record(fmt.Sprintf("read %d lines", strings.Count(string(data), "\n")))
+	})
+
+	panics.Add(2)
+	Info("first")
+	Info("second")
+	if _, err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	Info("third")
+	if _, err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("rotate hooks did not run")
+		}
+	}
+	panics.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"compress", "read 2 lines", "compress", "read 1 lines"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Errorf("hooks ran as %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ByteUnits selects the units used by Bytes and Rate.
+type ByteUnits int
+
+const (
+	// BinaryUnits uses powers of 1024: KiB, MiB, GiB and so on.
+	BinaryUnits ByteUnits = iota
+
+	// DecimalUnits uses powers of 1000: kB, MB, GB and so on.
+	DecimalUnits
+)
+
+var (
+	humanUnits     atomic.Int32
+	humanPrecision atomic.Int32
+)
+
+func init() {
+	humanPrecision.Store(1)
+}
+
+// SetHumanUnits selects the units used when rendering Bytes and Rate
+// values. The default is BinaryUnits.
+func SetHumanUnits(u ByteUnits) {
+	humanUnits.Store(int32(u))
+}
+
+// SetHumanPrecision sets the number of decimals shown for Bytes and Rate
+// values above one kilobyte. The default is 1.
+func SetHumanPrecision(decimals int) {
+	if decimals < 0 {
+		decimals = 0
+	}
+	humanPrecision.Store(int32(decimals))
+}
+
+// ByteSize is a number of bytes that renders in human-readable units, such
+// as "700.0 MiB", when formatted with %v or %s.
+type ByteSize int64
+
+// Bytes returns n as a ByteSize, for use as a printf argument:
+//
+//	logger.Info("copied %v in %v", logger.Bytes(n), logger.Dur(d))
+func Bytes(n int64) ByteSize {
+	return ByteSize(n)
+}
+
+// String implements fmt.Stringer.
+func (b ByteSize) String() string {
+	return formatBytes(float64(b))
+}
+
+// formatBytes renders n bytes with the configured units and precision.
+func formatBytes(n float64) string {
+	base, units := 1024.0, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	if ByteUnits(humanUnits.Load()) == DecimalUnits {
+		base, units = 1000.0, []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	}
+
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	if n < base {
+		return sign + strconv.FormatFloat(n, 'f', 0, 64) + " B"
+	}
+
+	prec := int(humanPrecision.Load())
+	i := 0
+	for n >= base && i < len(units)-1 {
+		n /= base
+		i++
+	}
+	text := strconv.FormatFloat(n, 'f', prec, 64)
+	if v, _ := strconv.ParseFloat(text, 64); v >= base && i < len(units)-1 {
+		// Rounding reached the next unit, as 1023.96 KiB does.
+		n /= base
+		i++
+		text = strconv.FormatFloat(n, 'f', prec, 64)
+	}
+	return sign + text + " " + units[i]
+}
+
+// Duration is a time.Duration that renders rounded to about three
+// significant digits, such as "12s" or "1.23ms", when formatted with %v or
+// %s.
+type Duration time.Duration
+
+// Dur returns d as a Duration, for use as a printf argument.
+func Dur(d time.Duration) Duration {
+	return Duration(d)
+}
+
+// String implements fmt.Stringer.
+func (d Duration) String() string {
+	return roundDuration(time.Duration(d)).String()
+}
+
+// roundDuration rounds d to about three significant digits, and to whole
+// seconds from a minute on.
+func roundDuration(d time.Duration) time.Duration {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= time.Minute:
+		return d.Round(time.Second)
+	case abs >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case abs >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	case abs >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond)
+	}
+	return d
+}
+
+// ByteRate is a throughput that renders in human-readable units per
+// second, such as "58.3 MiB/s", when formatted with %v or %s.
+type ByteRate struct {
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Rate returns the throughput of transferring n bytes in d.
+func Rate(n int64, d time.Duration) ByteRate {
+	return ByteRate{Bytes: n, Duration: d}
+}
+
+// String implements fmt.Stringer. A rate over a non-positive duration is
+// rendered as "n/a".
+func (r ByteRate) String() string {
+	if r.Duration <= 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%s/s", formatBytes(float64(r.Bytes)/r.Duration.Seconds()))
+}
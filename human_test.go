@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHumanBytes(t *testing.T) {
+	t.Cleanup(func() {
+		SetHumanUnits(BinaryUnits)
+		SetHumanPrecision(1)
+	})
+	tests := []struct {
+		units     ByteUnits
+		precision int
+		n         int64
+		want      string
+	}{
+		{BinaryUnits, 1, 0, "0 B"},
+		{BinaryUnits, 1, 1023, "1023 B"},
+		{BinaryUnits, 1, 1024, "1.0 KiB"},
+		{BinaryUnits, 1, 1536, "1.5 KiB"},
+		{BinaryUnits, 1, 734003200, "700.0 MiB"},
+		{BinaryUnits, 1, 1048575, "1.0 MiB"}, // 1023.999 KiB rounds up a unit
+		{BinaryUnits, 2, 1 << 40, "1.00 TiB"},
+		{BinaryUnits, 1, 1<<50 - 1, "1.0 PiB"},
+		{BinaryUnits, 1, 1<<50 - 1<<38, "1023.8 TiB"},
+		{BinaryUnits, 0, 1536, "2 KiB"},
+		{BinaryUnits, 1, -2048, "-2.0 KiB"},
+		{BinaryUnits, 1, 1<<63 - 1, "8.0 EiB"},
+		{DecimalUnits, 1, 999, "999 B"},
+		{DecimalUnits, 1, 1000, "1.0 kB"},
+		{DecimalUnits, 1, 999950, "1.0 MB"},
+		{DecimalUnits, 3, 1234567, "1.235 MB"},
+	}
+	for _, tt := range tests {
+		SetHumanUnits(tt.units)
+		SetHumanPrecision(tt.precision)
+		if got := fmt.Sprintf("%v", Bytes(tt.n)); got != tt.want {
+			t.Errorf("Bytes(%d) with units %d and precision %d = %q, want %q", tt.n, tt.units, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{999 * time.Nanosecond, "999ns"},
+		{1234 * time.Nanosecond, "1.23µs"},
+		{1234567 * time.Nanosecond, "1.23ms"},
+		{12345 * time.Millisecond, "12.35s"},
+		{90*time.Second + 400*time.Millisecond, "1m30s"},
+		{-1500 * time.Microsecond, "-1.5ms"},
+		{3*time.Hour + 25*time.Minute + 59*time.Second + 700*time.Millisecond, "3h26m0s"},
+	}
+	for _, tt := range tests {
+		if got := Dur(tt.d).String(); got != tt.want {
+			t.Errorf("Dur(%v) = %q, want %q", time.Duration(tt.d), got, tt.want)
+		}
+	}
+}
+
+func TestHumanRate(t *testing.T) {
+	tests := []struct {
+		n    int64
+		d    time.Duration
+		want string
+	}{
+		{61132800, time.Second, "58.3 MiB/s"},
+		{512, 2 * time.Second, "256 B/s"},
+		{1 << 30, 500 * time.Millisecond, "2.0 GiB/s"},
+		{100, 0, "n/a"},
+		{100, -time.Second, "n/a"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(Rate(tt.n, tt.d)); got != tt.want {
+			t.Errorf("Rate(%d, %v) = %q, want %q", tt.n, tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WithDateLayout organizes log files in directories derived from the
+// current date.
+//
+// The pattern is a time layout using "/" as the separator, such as
+// "2006/01/02". It is expanded below the directory of the filename passed
+// to InitLogger, so "logs/app.log" with the pattern above is written to
+// "logs/2024/05/03/app.log". Directories are created as needed, and the
+// logger switches to the new path on the first entry after the date rolls
+// over.
+func WithDateLayout(pattern string) Option {
+	return func(c *config) {
+		c.dateLayout = pattern
+	}
+}
+
+// validateDateLayout reports whether pattern expands to a relative path
+// that stays inside the log directory.
+func validateDateLayout(pattern string) error {
+	sample := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(pattern)
+	if !filepath.IsLocal(filepath.FromSlash(sample)) {
+		return fmt.Errorf("invalid date layout %q: must expand to a relative path", pattern)
+	}
+	return nil
+}
+
+// resolveLogPath returns the path of the log file opened at time now,
+// expanding the filename template t and applying the configured date
+// layout.
+func resolveLogPath(t *filenameTemplate, now time.Time) string {
+	name := t.expand(now)
+	if cfg.dateLayout == "" {
+		return name
+	}
+
+	dir, base := filepath.Split(name)
+	parts := []string{dir}
+	parts = append(parts, strings.Split(now.Format(cfg.dateLayout), "/")...)
+	parts = append(parts, base)
+	return filepath.Join(parts...)
+}
+
+// dateKey returns the parts of the log path that depend on the date of
+// now: the directories of the date layout and the {date} placeholder of
+// the filename. The caller must hold mu.
+func dateKey(now time.Time) string {
+	var key string
+	if cfg.dateLayout != "" {
+		key = now.Format(cfg.dateLayout)
+	}
+	if nameTemplate != nil && nameTemplate.dated() {
+		key += "|" + now.Format(templateDateLayout)
+	}
+	return key
+}
+
+// rollDateLayout switches to the file for the current date when the date
+// layout or the {date} placeholder of the filename produce a different
+// path than the active one.
+//
+// The check runs at most once per second, and never moves back to an
+// earlier date, so entries racing across midnight cannot flip the output
+// between two directories. The caller must hold mu.
+func rollDateLayout(now time.Time) {
+	if logFile == nil || nameTemplate == nil || (cfg.dateLayout == "" && !nameTemplate.dated()) {
+		return
+	}
+
+	sec := now.Unix()
+	if sec <= dateChecked {
+		return
+	}
+	dateChecked = sec
+
+	key := dateKey(now)
+	if key == dateDir {
+		return
+	}
+
+	path := resolveLogPath(nameTemplate, now)
+	f, err := openLogFile(path, OpenAppend)
+	if err != nil {
+		return
+	}
+	dateDir = key
+	swapFile(f, path)
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+)
+
+// levels holds the global level threshold and the module overrides.
+var levels = newPrefixSettings(DEBUG)
+
+// SetLevel sets the global level threshold. Entries below it are
+// discarded unless a module override applies. The default is DEBUG, which
+// writes every entry.
+func SetLevel(level LogLevel) {
+	levels.setGlobal(level)
+}
+
+// SetModuleLevel overrides the level threshold for the packages whose
+// import path is prefix or lies below it, such as
+// "github.com/acme/app/storage". When several overrides match a package,
+// the longest prefix wins.
+func SetModuleLevel(prefix string, level LogLevel) {
+	levels.set(prefix, level)
+}
+
+// ClearModuleLevel removes the override set for prefix with
+// SetModuleLevel.
+func ClearModuleLevel(prefix string) {
+	levels.clear(prefix)
+}
+
+// ModuleLevels returns the current module overrides, keyed by prefix.
+func ModuleLevels() map[string]LogLevel {
+	cur := levels.load()
+	overrides := make(map[string]LogLevel, len(cur.modules))
+	for _, m := range cur.modules {
+		overrides[m.prefix] = m.value
+	}
+	return overrides
+}
+
+// enabled reports whether an entry at level logged from the frame skip
+// levels above the caller of enabled passes the threshold of its package.
+//
+// Without module overrides this is a single atomic load. Otherwise the
+// calling package is resolved from the program counter, and the decision
+// is cached per PC until the overrides change.
+func enabled(level LogLevel, skip int) bool {
+	t := levels.load()
+	if len(t.modules) == 0 {
+		return level >= t.global
+	}
+
+	if f, ok := callerFrame(skip + 1); ok {
+		return level >= t.lookup(f.PC)
+	}
+
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return level >= t.global
+	}
+	return level >= t.lookup(pcs[0])
+}
+
+// enabledAt is like enabled for an entry attributed to the code at pc.
+func enabledAt(level LogLevel, pc uintptr) bool {
+	t := levels.load()
+	if len(t.modules) == 0 || pc == 0 {
+		return level >= t.global
+	}
+	return level >= t.lookup(pc)
+}
+
+// packagePath returns the import path of the package defining the
+// function with the given fully qualified name, such as
+// "github.com/acme/app/storage.(*DB).Get".
+func packagePath(funcName string) string {
+	slash := strings.LastIndexByte(funcName, '/')
+	if dot := strings.IndexByte(funcName[slash+1:], '.'); dot >= 0 {
+		return funcName[:slash+1+dot]
+	}
+	return funcName
+}
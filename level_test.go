@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLevelValues(t *testing.T) {
+	// INFO, WARN and ERROR kept their values when DEBUG was added.
+	if DEBUG != -1 || INFO != 0 || WARN != 1 || ERROR != 2 {
+		t.Fatalf("levels = %d %d %d %d, want -1 0 1 2", DEBUG, INFO, WARN, ERROR)
+	}
+}
+
+func TestLevelCounts(t *testing.T) {
+	ResetErrorCount()
+	defer ResetErrorCount()
+
+	countLevels([]Entry{{Level: DEBUG}, {Level: INFO}, {Level: ERROR}, {Level: ERROR}})
+	for _, tc := range []struct {
+		level LogLevel
+		want  int64
+	}{{DEBUG, 1}, {INFO, 1}, {WARN, 0}, {ERROR, 2}} {
+		if got := LevelCount(tc.level); got != tc.want {
+			t.Errorf("LevelCount(%d) = %d, want %d", tc.level, got, tc.want)
+		}
+	}
+	if got := CurrentStats().Logged; got != [numLevels]int64{1, 1, 0, 2} {
+		t.Errorf("Stats.Logged = %v", got)
+	}
+}
+
+// TestLevelCountsConcurrent checks that no increments are lost between
+// goroutines logging at once and that resets racing with them leave
+// consistent counts.
+func TestLevelCountsConcurrent(t *testing.T) {
+	initTestLogger(t, "app.log")
+	ResetErrorCount()
+	defer ResetErrorCount()
+
+	const goroutines, entries = 8, 500
+	logAll := func() {
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < entries; i++ {
+					Error("failure %d", i)
+					Warn("warning %d", i)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	logAll()
+	if got := ErrorCount(); got != goroutines*entries {
+		t.Errorf("ErrorCount = %d after %d errors", got, goroutines*entries)
+	}
+	if got := LevelCount(WARN); got != goroutines*entries {
+		t.Errorf("LevelCount(WARN) = %d after %d warnings", got, goroutines*entries)
+	}
+
+	var stop atomic.Bool
+	resetDone := make(chan struct{})
+	go func() {
+		defer close(resetDone)
+		for !stop.Load() {
+			ResetErrorCount()
+			if n := ErrorCount(); n < 0 || n > goroutines*entries {
+				t.Errorf("ErrorCount = %d while resetting", n)
+			}
+		}
+	}()
+	logAll()
+	stop.Store(true)
+	<-resetDone
+	if n := ErrorCount(); n < 0 || n > goroutines*entries {
+		t.Errorf("ErrorCount = %d after racing resets, want at most %d", n, goroutines*entries)
+	}
+
+	ResetErrorCount()
+	if ErrorCount() != 0 || LevelCount(WARN) != 0 || ExitCode(0, 1) != 0 {
+		t.Errorf("counts after a quiet reset: errors %d, warnings %d", ErrorCount(), LevelCount(WARN))
+	}
+	Error("one more")
+	if ErrorCount() != 1 || ExitCode(0, 1) != 1 {
+		t.Errorf("ErrorCount = %d, ExitCode = %d after one error, want 1 and 1", ErrorCount(), ExitCode(0, 1))
+	}
+}
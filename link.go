@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LinkFallback selects what WithCurrentLink does when symbolic links cannot
+// be created, for example on Windows without the required privilege.
+type LinkFallback int
+
+// Available link fallbacks.
+//
+// LinkFallbackWarn logs a warning to the active log file, once.
+// LinkFallbackFile writes the path of the active log file into a text file
+// named after the link with a ".current" suffix.
+const (
+	LinkFallbackWarn LinkFallback = iota
+	LinkFallbackFile
+)
+
+// linkWarned records whether the symlink warning has already been logged.
+var linkWarned bool
+
+// WithCurrentLink maintains a symbolic link at path pointing to the active
+// log file.
+//
+// The link is updated when the logger is initialized and whenever it
+// switches to a new file, so "tail -F" on the link always follows the
+// newest log. The update is atomic: a temporary link is created next to
+// path and renamed over it. The target is stored relative to the link's
+// directory when possible. If symlinks are not available, fallback decides
+// what happens instead.
+func WithCurrentLink(path string, fallback LinkFallback) Option {
+	return func(c *config) {
+		c.currentLink = path
+		c.linkFallback = fallback
+	}
+}
+
+// updateCurrentLink points the configured current link at target. The
+// caller must hold mu.
+func updateCurrentLink(target string) {
+	if cfg.currentLink == "" {
+		return
+	}
+
+	rel := target
+	if r, err := filepath.Rel(filepath.Dir(cfg.currentLink), target); err == nil {
+		rel = r
+	}
+
+	err := replaceSymlink(rel, cfg.currentLink)
+	if err == nil {
+		return
+	}
+
+	switch cfg.linkFallback {
+	case LinkFallbackFile:
+		if err := replaceFile(cfg.currentLink+".current", []byte(rel+"\n")); err != nil {
+			logInternal(WARN, fmt.Sprintf("failed to record current log file: %v", err))
+		}
+	default:
+		if !linkWarned {
+			linkWarned = true
+			logInternal(WARN, fmt.Sprintf("failed to update current log link: %v", err))
+		}
+	}
+}
+
+// replaceSymlink atomically makes link a symbolic link to target.
+func replaceSymlink(target, link string) error {
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// replaceFile atomically replaces the contents of path with data.
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, cfg.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
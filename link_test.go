@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCurrentLinkFollowsRotation rotates twice and checks that the link
+// points at the newest file each time.
+func TestCurrentLinkFollowsRotation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need extra privileges on Windows")
+	}
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local))
+	dir := t.TempDir()
+	link := filepath.Join(dir, "current.log")
+	if err := InitLogger(filepath.Join(dir, "app-{time}.log"), WithCurrentLink(link, LinkFallbackWarn)); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	for i, want := range []string{"app-090000.log", "app-090001.log", "app-090002.log"} {
+		if i > 0 {
+			clock.Advance(time.Second)
+			if _, err := Rotate(); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+		}
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != want {
+			t.Errorf("after %d rotations the link points at %q, want %q", i, target, want)
+		}
+
+		Info("entry %d", i)
+		lines := readLines(t, link)
+		if len(lines) != 1 || !strings.HasSuffix(lines[0], fmt.Sprintf(" - entry %d", i)) {
+			t.Errorf("after %d rotations the link reads %q", i, lines)
+		}
+	}
+	if _, err := os.Lstat(link + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary link left behind: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package logger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// staleLockAge is the age after which a lock file is assumed to belong to
+// a process that died while holding it.
+const staleLockAge = 30 * time.Second
+
+// fileLock is an advisory lock shared between processes writing to the
+// same log file.
+//
+// Platforms without flock semantics fall back to a lock file created with
+// O_EXCL: the process that manages to create it holds the lock, and
+// removes it on unlock. This approach has no notion of shared locks, so
+// writes are serialized across processes as well, and a lock left behind
+// by a crashed process is only broken after staleLockAge.
+type fileLock struct {
+	path string
+}
+
+// newFileLock prepares a lock held through the file at path.
+func newFileLock(path string) (*fileLock, error) {
+	return &fileLock{path: path}, nil
+}
+
+// lock acquires the lock, blocking until it is available. The exclusive
+// flag is accepted for parity with the flock implementation; every lock is
+// exclusive here.
+//
+// Unlike the rest of the package, lock uses the system clock: lock file
+// ages come from the file system, and other processes are not affected by
+// SetClock.
+func (l *fileLock) lock(exclusive bool) error {
+	for {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, cfg.fileMode)
+		if err == nil {
+			return f.Close()
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if info, err := os.Stat(l.path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(l.path)
+			continue
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// relock converts the held lock to an exclusive or a shared one. Every
+// lock is exclusive here, so there is nothing to do.
+func (l *fileLock) relock(exclusive bool) error {
+	return nil
+}
+
+// unlock releases a lock previously acquired with lock.
+func (l *fileLock) unlock() error {
+	return os.Remove(l.path)
+}
+
+// close releases resources held by the lock.
+func (l *fileLock) close() error {
+	return nil
+}
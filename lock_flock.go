@@ -0,0 +1,59 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory lock shared between processes writing to the
+// same log file.
+//
+// It is implemented with flock(2) on a sidecar file next to the log. The
+// sidecar is never renamed, so all processes keep locking the same inode
+// even after the log file itself has been rotated.
+type fileLock struct {
+	f *os.File
+}
+
+// newFileLock opens (creating if necessary) the lock file at path.
+func newFileLock(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, cfg.fileMode)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// lock acquires the lock, blocking until it is available. Shared locks
+// are used for writes, exclusive locks for rotation and reopen.
+func (l *fileLock) lock(exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(l.f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// relock converts the held lock to an exclusive or a shared one. The
+// conversion is not atomic: another process may take the lock in between,
+// so state checked under the old lock must be checked again.
+func (l *fileLock) relock(exclusive bool) error {
+	return l.lock(exclusive)
+}
+
+// unlock releases a lock previously acquired with lock.
+func (l *fileLock) unlock() error {
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
+
+// close releases the lock file.
+func (l *fileLock) close() error {
+	return l.f.Close()
+}
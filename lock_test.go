@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	lockWorkers = 4
+	lockLines   = 300
+)
+
+// lockLine matches the lines written by TestFileLockHelper. The padding
+// makes torn or interleaved writes easy to spot.
+var lockLine = regexp.MustCompile(`^worker (\d+) line (\d+) (x{200})$`)
+
+// TestFileLockHelper is run in child processes by TestFileLockProcesses.
+func TestFileLockHelper(t *testing.T) {
+	path := os.Getenv("LOGGER_LOCK_HELPER")
+	if path == "" {
+		t.Skip("only run as a helper process")
+	}
+	worker, _ := strconv.Atoi(os.Getenv("LOGGER_LOCK_WORKER"))
+
+	if err := InitLogger(path, WithFileLock()); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	pad := strings.Repeat("x", 200)
+	for i := 0; i < lockLines; i++ {
+		if i == lockLines/2 {
+			// Worker 0 rotates halfway through; the others wait for the
+			// rotation so that their second half must reach the new file.
+			if worker == 0 {
+				if _, err := Rotate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for {
+				if backups, _ := listBackups(path); len(backups) > 0 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		Info("worker %d line %d %s", worker, i, pad)
+	}
+}
+
+func TestFileLockProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shared.log")
+
+	var wg sync.WaitGroup
+	errs := make([]error, lockWorkers)
+	for w := 0; w < lockWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestFileLockHelper$")
+			cmd.Env = append(os.Environ(), "LOGGER_LOCK_HELPER="+path, "LOGGER_LOCK_WORKER="+strconv.Itoa(w))
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs[w] = fmt.Errorf("worker %d: %v\n%s", w, err, out)
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := listBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("found %d backups, want exactly one rotation", len(backups))
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range []string{backups[0].path, path} {
+		active := name == path
+		for _, line := range readLines(t, name) {
+			e, err := ParseLine(line)
+			if err != nil {
+				t.Fatalf("%s: torn line %q: %v", name, line, err)
+			}
+			if !strings.HasPrefix(e.Message, "worker ") {
+				continue
+			}
+			m := lockLine.FindStringSubmatch(e.Message)
+			if m == nil {
+				t.Fatalf("%s: interleaved line %q", name, line)
+			}
+			if n, _ := strconv.Atoi(m[2]); n >= lockLines/2 && !active {
+				t.Errorf("%s: line %q is on the wrong side of the rotation", name, line)
+			}
+			key := m[1] + "/" + m[2]
+			if seen[key] {
+				t.Fatalf("%s: duplicate line %q", name, line)
+			}
+			seen[key] = true
+		}
+	}
+	if want := lockWorkers * lockLines; len(seen) != want {
+		t.Errorf("found %d lines, want %d", len(seen), want)
+	}
+}
@@ -1,13 +1,16 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,59 +19,247 @@ type LogLevel int
 
 // Available log levels.
 //
+// DEBUG is used for detailed diagnostic messages.
 // INFO is used for general informational messages.
 // WARN is used for non-critical issues that might require attention.
 // ERROR is used for errors and failures.
+//
+// DEBUG was added below the original levels, so INFO remains the zero
+// value and the values of INFO, WARN and ERROR are unchanged.
 const (
-	INFO LogLevel = iota
+	DEBUG LogLevel = iota - 1
+	INFO
 	WARN
 	ERROR
 )
 
+// numLevels is the number of levels. Tables with an element per level are
+// indexed by level - DEBUG.
+const numLevels = int(ERROR-DEBUG) + 1
+
 var (
-	logFile    *os.File
-	logger     *log.Logger
-	once       sync.Once
-	timeFormat = "2006-01-02 15:04:05"
+	mu           sync.Mutex
+	logFile      *os.File
+	logPath      string
+	nameTemplate *filenameTemplate
+	dateDir      string // dateKey of the active file
+	dateChecked  int64
+
+	writeFailures int
+	writeErr      error
+
+	closed         bool
+	closedReported bool
+	lock           *fileLock
+	logger         *log.Logger
+	once           sync.Once
+	timeFormat     = "2006-01-02 15:04:05"
+
+	lastTimestamp atomic.Pointer[cachedTimestamp]
 )
 
+// cachedTimestamp is the most recently rendered timestamp together with
+// everything that determines its text: the layout, the location and the
+// instant truncated to the precision of the layout.
+//
+// Values are never mutated after being published, so readers always see a
+// consistent string.
+type cachedTimestamp struct {
+	layout string
+	loc    *time.Location
+	unit   int64
+	text   string
+}
+
+// formatTimestamp renders t using the configured time format.
+//
+// Consecutive entries logged within the same unit of the layout's precision
+// (one second for the default layout) reuse the previously rendered string
+// instead of formatting it again. The cache is keyed on the layout and the
+// location as well, so changing either invalidates it automatically.
+func formatTimestamp(t time.Time) string {
+	layout := timeFormat
+	unit := t.UnixNano() / int64(layoutPrecision(layout))
+
+	if c := lastTimestamp.Load(); c != nil && c.unit == unit && c.layout == layout && c.loc == t.Location() {
+		return c.text
+	}
+
+	text := t.Format(layout)
+	lastTimestamp.Store(&cachedTimestamp{
+		layout: layout,
+		loc:    t.Location(),
+		unit:   unit,
+		text:   text,
+	})
+	return text
+}
+
+// layoutPrecision returns the smallest time step that can change the output
+// of layout: a second, or a fraction of it when the seconds element "05" is
+// followed by fractional seconds such as ".000" or ",999999". Dots and
+// commas elsewhere, as in "2006.01.02", are literal text.
+func layoutPrecision(layout string) time.Duration {
+	for i := 2; i+1 < len(layout); i++ {
+		if layout[i] != '.' && layout[i] != ',' || layout[i-2:i] != "05" {
+			continue
+		}
+		digit := layout[i+1]
+		if digit != '0' && digit != '9' {
+			continue
+		}
+		n := 0
+		for i+1+n < len(layout) && layout[i+1+n] == digit {
+			n++
+		}
+		if end := i + 1 + n; end < len(layout) && '0' <= layout[end] && layout[end] <= '9' {
+			continue
+		}
+		if n > 9 {
+			n = 9
+		}
+		precision := time.Second
+		for ; n > 0; n-- {
+			precision /= 10
+		}
+		return precision
+	}
+	return time.Second
+}
+
 // InitLogger initializes the global logger with the given log file path.
 //
 // The function ensures that the directory for the log file exists,
 // creates it if necessary, and then opens the log file for appending.
 // If the logger is successfully initialized, subsequent logging
-// functions (Info, Warn, Error) will write to this file.
-func InitLogger(filename string) error {
-	var err error
+// functions (Info, Warn, Error) will write to this file. Calling
+// InitLogger again replaces the active file, closing the previous one.
+//
+// The filename may contain placeholders such as {date}, {host} or {pid},
+// which are expanded when the file is opened; see filenameTemplate for the
+// full list. Options can be supplied to change how the file and its
+// directory are created; see WithFileMode, WithDirMode and WithOpenMode.
+func InitLogger(filename string, opts ...Option) error {
 	fmt.Println("---------")
 
-	dir := filepath.Dir(filename)
+	mu.Lock()
+	defer mu.Unlock()
 
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create log directory: %w", err)
+	c := defaultConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if err := c.validate(); err != nil {
+		return err
 	}
 
-	logFile, err = os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
+	prev := cfg
+	cfg = c
+	lastDiskCheck = time.Time{}
+	if err := openOutput(filename, cfg.openMode); err != nil {
+		cfg = prev
 		fmt.Println("log error:", err.Error())
 		return err
 	}
+	resetFallback()
+	resetTriggerBuffer()
 
-	logger = log.New(logFile, "", 0)
 	fmt.Println("---------")
 	return nil
 }
 
+// openLogFile creates the directory for filename if necessary and opens
+// the file according to mode, applying the configured permissions.
+//
+// Every path that creates a log file goes through this function so that
+// new files always get the same modes as the initial one.
+func openLogFile(filename string, mode OpenMode) (*os.File, error) {
+	if err := makeLogDir(filepath.Dir(filename)); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(filename, mode.flags(), cfg.fileMode)
+	if err != nil {
+		if mode == OpenExclusive && errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("log file already exists: %w", err)
+		}
+		return nil, err
+	}
+
+	if cfg.enforceFileMode {
+		if err := f.Chmod(cfg.fileMode); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to set log file mode: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// makeLogDir creates dir and any missing parents with the configured mode.
+//
+// When the directory mode is enforced, every directory created by this call
+// is chmod-ed afterwards to undo the effect of the umask.
+func makeLogDir(dir string) error {
+	var created []string
+	if cfg.enforceDirMode {
+		for d := dir; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(d); err == nil {
+				break
+			}
+			created = append(created, d)
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, cfg.dirMode); err != nil {
+		return err
+	}
+
+	for _, d := range created {
+		if err := os.Chmod(d, cfg.dirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close closes the underlying log file if it is open.
 //
 // It should be called when the application is shutting down
 // to ensure that all buffered log data is flushed and the
 // file descriptor is released.
+//
+// Close stops accepting new entries before closing the file. The returned
+// error includes any failures to write entries since the logger was
+// initialized, together with how many entries were lost, and names the
+// outputs added with AddOutput that did not drain in time. Calling Close
+// more than once is safe; subsequent calls do nothing and return nil.
 func Close() error {
-	if logFile != nil {
-		return logFile.Close()
+	mu.Lock()
+	defer mu.Unlock()
+
+	var errs []error
+	if logger != nil {
+		flushOpenGroups()
+		if err := drainOutputs(); err != nil {
+			errs = append(errs, err)
+		}
+		closed = true
+		closedReported = false
 	}
-	return nil
+	logger = nil
+
+	if writeFailures > 0 {
+		errs = append(errs, fmt.Errorf("failed to write %d log entries: %w", writeFailures, writeErr))
+		writeFailures = 0
+		writeErr = nil
+	}
+	if err := closeOutput(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
 // Log writes a formatted log entry with the given level and message.
@@ -76,50 +267,260 @@ func Close() error {
 // Log automatically captures information about the caller (file name,
 // line number, and function name) and prepends a timestamp and process ID.
 // It is the low-level logging function that is wrapped by Info, Warn, and Error.
+//
+// Entries below the level threshold of the calling package are discarded;
+// see SetLevel and SetModuleLevel.
 func Log(level LogLevel, message string) {
-	if logger == nil {
-		return
+	logAt(level, 2, func() string { return message })
+}
+
+// newEntry builds the entry for message logged at now.
+//
+// The caller information is taken from the frame skip levels above the
+// caller of newEntry, following the convention of runtime.Caller.
+func newEntry(now time.Time, level LogLevel, skip int, message string) Entry {
+	return entryAt(now, level, captureCallSite(skip+1), message)
+}
+
+// entryAt builds the entry for message logged at now and attributed to
+// the call site cs.
+func entryAt(now time.Time, level LogLevel, cs callSite, message string) Entry {
+	return Entry{
+		Time:    now,
+		Level:   level,
+		PID:     os.Getpid(),
+		File:    cs.file,
+		Line:    cs.line,
+		Func:    cs.fn,
+		Message: message,
 	}
+}
+
+// callSite is the source location an entry is attributed to.
+type callSite struct {
+	pc   uintptr
+	file string
+	line int
+	fn   string
+}
 
-	pc, file, line, ok := runtime.Caller(2)
+// captureCallSite returns the call site skip frames above the caller of
+// captureCallSite, with the file and function names shortened.
+//
+// With wrapper packages registered, the call site is the first frame
+// outside of them instead; see RegisterCallerSkipPrefix.
+func captureCallSite(skip int) callSite {
+	if f, ok := callerFrame(skip + 1); ok {
+		return shortCallSite(f.PC, f.File, f.Line, f.Function)
+	}
+
+	pc, file, line, ok := runtime.Caller(skip + 1)
 	if !ok {
 		file = "unknown"
 		line = 0
 	}
+	return shortCallSite(pc, file, line, runtime.FuncForPC(pc).Name())
+}
 
+// shortCallSite returns the call site at pc with the file and function
+// names shortened.
+func shortCallSite(pc uintptr, file string, line int, funcName string) callSite {
 	shortFile := file
 	if lastSlash := strings.LastIndex(file, "/"); lastSlash >= 0 {
 		shortFile = file[lastSlash+1:]
 	}
 
-	funcName := runtime.FuncForPC(pc).Name()
 	if lastDot := strings.LastIndex(funcName, "."); lastDot >= 0 {
 		funcName = funcName[lastDot+1:]
 	}
 
-	levelStr := ""
-	switch level {
-	case INFO:
-		levelStr = "INFO"
-	case WARN:
-		levelStr = "WARN"
-	case ERROR:
-		levelStr = "ERR"
+	return callSite{pc: pc, file: shortFile, line: line, fn: funcName}
+}
+
+// logInternal writes an entry reporting a problem of the logger itself to
+// the active log file. The caller must hold mu.
+func logInternal(level LogLevel, message string) {
+	if logger == nil {
+		return
 	}
+	e := newEntry(clockNow(), level, 1, message)
+	emit(&e)
+}
 
-	pid := os.Getpid()
+// write formats e and emits it to the active log file.
+//
+// When file locking is enabled the write happens under the shared lock,
+// after making sure the file has not been rotated away by another process.
+//
+// After Close, entries are dropped: the dropped counter is incremented and
+// the error handler is notified of the first one with ErrClosed. A write
+// that acquired the lock before Close completes normally.
+//
+// Entries are also dropped while the disk guard has suspended writing; see
+// WithDiskGuard.
+func write(e Entry) {
+	writeEntries([]Entry{e})
+}
 
-	logMsg := fmt.Sprintf("%s [%s] (%d)%s:%d %s - %s",
-		time.Now().Format(timeFormat),
-		levelStr,
-		pid,
-		shortFile,
-		line,
-		funcName,
-		message,
-	)
+// writeEntries writes es to the active log file as one contiguous block,
+// like write does for a single entry.
+func writeEntries(es []Entry) {
+	mu.Lock()
+	if logger == nil {
+		notify := false
+		if closed {
+			dropped.Add(uint64(len(es)))
+			notify = !closedReported
+			closedReported = true
+		}
+		mu.Unlock()
+		if notify {
+			reportError(ErrClosed)
+		}
+		return
+	}
+	var notify error
+	defer func() {
+		mu.Unlock()
+		if notify != nil {
+			reportError(notify)
+		}
+	}()
+	notify = writeLocked(es)
+}
+
+// writeLocked writes es to the active log file and returns an error to
+// pass to the error handler, if any. The caller must hold mu and the
+// logger must be open.
+func writeLocked(es []Entry) error {
+	if lock != nil && lock.lock(false) == nil {
+		defer lock.unlock()
+		if logFileMoved() && lock.relock(true) == nil {
+			reopenIfMoved()
+			lock.relock(false)
+		}
+	}
+	rollDateLayout(es[0].Time)
+	countErrors(es)
+	countLevels(es)
+
+	ok, notify := guardAllows(es[0].Time)
+	if !ok {
+		diskDropped.Add(uint64(len(es)))
+		return notify
+	}
 
-	logger.Println(logMsg)
+	for i := range es {
+		replayHeld(&es[i])
+	}
+
+	var err error
+	if len(es) == 1 {
+		err = emit(&es[0])
+	} else {
+		var rec []byte
+		for i := range es {
+			rec = append(rec, encodeEntry(&es[i])...)
+		}
+		err = emitRecord(rec, es)
+	}
+	if err != nil {
+		writeFailures += len(es)
+		if writeErr == nil {
+			writeErr = err
+		}
+	}
+	return notify
+}
+
+// emit encodes e with the configured format and writes it to the output.
+// The caller must hold mu.
+//
+// With fallback outputs configured, the record goes to the first healthy
+// output instead; see WithFallback.
+func emit(e *Entry) error {
+	rec := encodeEntry(e)
+	return emitRecord(rec, []Entry{*e})
+}
+
+// emitRecord writes rec, holding the encoded entries es, to the output
+// with a single call, and queues it for the outputs added with AddOutput
+// and AddEntryOutput. The caller must hold mu.
+func emitRecord(rec []byte, es []Entry) error {
+	bytesSinceCheck += int64(len(rec))
+	fanOut(rec, es)
+	if len(cfg.fallbacks) > 0 {
+		return writeFallback(es[0].Time, rec)
+	}
+	_, err := logger.Writer().Write(rec)
+	return err
+}
+
+// encodeEntry returns the record for e in the configured format, including
+// the trailing newline of text formats. It first assigns the ID of e if
+// event IDs are enabled and sanitizes its message if requested. The caller
+// must hold mu.
+func encodeEntry(e *Entry) []byte {
+	if cfg.eventIDs && e.ID == "" {
+		e.ID = nextEventID(e.Time)
+	}
+	if cfg.sanitize {
+		e.Message = sanitize(e.Message)
+	}
+	if cfg.binary {
+		binarySeq++
+		return appendBinaryRecord(nil, binarySeq, e)
+	}
+	line := cfg.formatter.Format(e)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	return []byte(line)
+}
+
+// logFileMoved reports whether the open file no longer is the file found
+// at logPath, which happens when another process rotated it.
+func logFileMoved() bool {
+	current, err := logFile.Stat()
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(logPath)
+	return err != nil || !os.SameFile(current, info)
+}
+
+// reopenIfMoved reopens logPath if logFileMoved. The caller must hold the
+// exclusive lock, so that processes noticing the same rotation do not race
+// to create the new file.
+func reopenIfMoved() {
+	if !logFileMoved() {
+		return
+	}
+
+	f, err := openLogFile(logPath, OpenAppend)
+	if err != nil {
+		return
+	}
+	swapFile(f, logPath)
+}
+
+// swapFile makes f, opened at path, the active log file and closes the
+// previous one. The caller must hold mu.
+func swapFile(f *os.File, path string) {
+	logFile.Close()
+	writeFileHeader(f)
+	logFile = f
+	logPath = path
+	logger.SetOutput(f)
+	updateCurrentLink(path)
+}
+
+// Debug logs a diagnostic message using printf-style formatting.
+//
+// Debug entries are only written when the level threshold of the calling
+// package is DEBUG, so they can be left in place in production code.
+func Debug(format string, args ...interface{}) {
+	logAt(DEBUG, 1, func() string { return fmt.Sprintf(format, args...) })
 }
 
 // Info logs an informational message using printf-style formatting.
@@ -127,8 +528,7 @@ func Log(level LogLevel, message string) {
 // The format string and arguments are passed to fmt.Sprintf
 // and the resulting string is logged with INFO level.
 func Info(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	Log(INFO, message)
+	logAt(INFO, 1, func() string { return fmt.Sprintf(format, args...) })
 }
 
 // Warn logs a warning message using printf-style formatting.
@@ -136,8 +536,7 @@ func Info(format string, args ...interface{}) {
 // This should be used for situations that are not fatal but may
 // require attention or indicate a potential problem.
 func Warn(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	Log(WARN, message)
+	logAt(WARN, 1, func() string { return fmt.Sprintf(format, args...) })
 }
 
 // Error logs an error message using printf-style formatting.
@@ -145,6 +544,5 @@ func Warn(format string, args ...interface{}) {
 // Use this for error conditions and failures that should be visible
 // in application logs.
 func Error(format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-	Log(ERROR, message)
+	logAt(ERROR, 1, func() string { return fmt.Sprintf(format, args...) })
 }
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLayoutPrecision(t *testing.T) {
+	for _, tt := range []struct {
+		layout string
+		want   time.Duration
+	}{
+		{"2006-01-02 15:04:05", time.Second},
+		{"2006-01-02 15:04:05.000", time.Millisecond},
+		{"2006-01-02 15:04:05,999999", time.Microsecond},
+		{"15:04:05.000000000", time.Nanosecond},
+		{"2006.01.02 15:04:05", time.Second},
+		{"02.01.2006 15:04", time.Second},
+		{"15:04:05.0001", time.Second},
+		{"2006.01.02 15:04:05.000", time.Millisecond},
+	} {
+		if got := layoutPrecision(tt.layout); got != tt.want {
+			t.Errorf("layoutPrecision(%q) = %v, want %v", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestTimestampAcrossSecond(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 1, 31, 23, 59, 59, 900e6, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	Info("before")
+	clock.Advance(50 * time.Millisecond)
+	Info("same second")
+	clock.Advance(100 * time.Millisecond)
+	Info("after")
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	want := []string{"2026-01-31 23:59:59", "2026-01-31 23:59:59", "2026-02-01 00:00:00"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), lines)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("line %d = %q, want timestamp %s", i, line, want[i])
+		}
+	}
+}
+
+func TestTimestampCacheConcurrent(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	done := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 1000; i++ {
+				ts := base.Add(time.Duration((i+g)%10) * time.Second)
+				if got, want := formatTimestamp(ts), ts.Format(timeFormat); got != want {
+					t.Errorf("formatTimestamp(%v) = %q, want %q", ts, got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	for g := 0; g < 4; g++ {
+		<-done
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	initTestLogger(b, "bench.log")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info("request %d handled", i)
+	}
+}
+
+func BenchmarkInfoAttrs(b *testing.B) {
+	initTestLogger(b, "bench.log")
+	b.Run("attrs", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			InfoAttrs("request handled", Int("id", i), Str("path", "/api"), DurAttr("took", time.Millisecond))
+		}
+	})
+	b.Run("fields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			InfoT("request handled", Fields{"id": i, "path": "/api", "took": time.Millisecond})
+		}
+	})
+	b.Run("printf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Info("request handled id=%v path=%v took=%v", i, "/api", time.Millisecond)
+		}
+	})
+}
+
+// TestAttrsMatchFields checks that attributes render like the same values
+// passed as template fields, which use fmt's %v.
+func TestAttrsMatchFields(t *testing.T) {
+	for _, tt := range []struct {
+		attr  Attr
+		value interface{}
+	}{
+		{Str("s", "hello world"), "hello world"},
+		{Str("s", ""), ""},
+		{Int("n", -42), -42},
+		{Int64("n", math.MinInt64), int64(math.MinInt64)},
+		{Uint64("n", math.MaxUint64), uint64(math.MaxUint64)},
+		{Float("f", 3.25), 3.25},
+		{Float("f", 1e21), 1e21},
+		{Float("f", math.Inf(-1)), math.Inf(-1)},
+		{Bool("b", true), true},
+		{Bool("b", false), false},
+		{DurAttr("d", 1500*time.Millisecond), 1500 * time.Millisecond},
+		{Err(errors.New("boom")), errors.New("boom")},
+		{Err(nil), nil},
+	} {
+		got := renderAttrs("msg", []Attr{tt.attr})
+		want := parseMessageTemplate("msg").render(Fields{tt.attr.Key: tt.value})
+		if got != want {
+			t.Errorf("%s: attribute renders %q, field renders %q", tt.attr.Key, got, want)
+		}
+	}
+
+	got := renderAttrs("msg", []Attr{Str("b", "2"), Int("a", 1)})
+	if got != "msg b=2 a=1" {
+		t.Errorf("attributes rendered %q, want them in the given order", got)
+	}
+}
+
+func BenchmarkFormatTimestamp(b *testing.B) {
+	ts := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			formatTimestamp(ts.Add(time.Duration(i%1000) * time.Microsecond))
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ts.Add(time.Duration(i%1000) * time.Microsecond).Format(timeFormat)
+		}
+	})
+}
+
+func TestLogRacingClose(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+	before := CurrentStats().Dropped
+
+	var closedErrs atomic.Int32
+	SetErrorHandler(func(err error) {
+		if errors.Is(err, ErrClosed) {
+			closedErrs.Add(1)
+		}
+	})
+	defer SetErrorHandler(nil)
+
+	const goroutines, entries = 8, 500
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			<-start
+			for i := 0; i < entries; i++ {
+				Info("entry %d/%d", g, i)
+			}
+		}(g)
+	}
+	close(start)
+	if err := Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	wg.Wait()
+
+	written := 0
+	for _, line := range readLines(t, filepath.Join(dir, "app.log")) {
+		if strings.Contains(line, " - entry ") {
+			written++
+		}
+	}
+	dropped := int(CurrentStats().Dropped - before)
+	if written+dropped != goroutines*entries {
+		t.Errorf("%d entries written and %d dropped, want %d in total", written, dropped, goroutines*entries)
+	}
+	if want := int32(min(dropped, 1)); closedErrs.Load() != want {
+		t.Errorf("ErrClosed reported %d times, want %d", closedErrs.Load(), want)
+	}
+}
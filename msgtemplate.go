@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Fields holds the values for the named placeholders of a message
+// template.
+type Fields map[string]interface{}
+
+// messageTemplate is a parsed message template: literal text alternating
+// with placeholder names.
+type messageTemplate struct {
+	parts []messagePart
+	keys  map[string]bool
+}
+
+// messagePart is either literal text or, when isKey is set, the name of
+// a placeholder.
+type messagePart struct {
+	text  string
+	isKey bool
+}
+
+// maxMessageTemplates bounds the number of cached templates, so that
+// templates built at run time cannot grow the cache without limit.
+const maxMessageTemplates = 1024
+
+var (
+	// messageTemplates caches parsed templates by their source, holding
+	// messageTemplateCount of them.
+	messageTemplates     sync.Map
+	messageTemplateCount atomic.Int32
+)
+
+// parseMessageTemplate parses tmpl, reusing the result of earlier calls
+// with the same template. Once maxMessageTemplates templates are cached,
+// further templates are parsed on every call.
+//
+// Placeholders are written as {name}; "{{" and "}}" stand for literal
+// braces. A "{" without a matching "}" is kept as literal text.
+func parseMessageTemplate(tmpl string) *messageTemplate {
+	if t, ok := messageTemplates.Load(tmpl); ok {
+		return t.(*messageTemplate)
+	}
+
+	t := &messageTemplate{keys: make(map[string]bool)}
+	var lit strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case (c == '{' || c == '}') && i+1 < len(tmpl) && tmpl[i+1] == c:
+			lit.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(tmpl[i+1:], '}')
+			if end <= 0 {
+				lit.WriteByte(c)
+				continue
+			}
+			if lit.Len() > 0 {
+				t.parts = append(t.parts, messagePart{text: lit.String()})
+				lit.Reset()
+			}
+			key := tmpl[i+1 : i+1+end]
+			t.parts = append(t.parts, messagePart{text: key, isKey: true})
+			t.keys[key] = true
+			i += end + 1
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	if lit.Len() > 0 {
+		t.parts = append(t.parts, messagePart{text: lit.String()})
+	}
+
+	if messageTemplateCount.Add(1) > maxMessageTemplates {
+		messageTemplateCount.Add(-1)
+	} else if _, loaded := messageTemplates.LoadOrStore(tmpl, t); loaded {
+		messageTemplateCount.Add(-1)
+	}
+	return t
+}
+
+// render substitutes fields into t. Placeholders without a value are
+// rendered as "{name!MISSING}", and fields no placeholder refers to are
+// appended as key=value pairs sorted by key.
+func (t *messageTemplate) render(fields Fields) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if !p.isKey {
+			b.WriteString(p.text)
+			continue
+		}
+		v, ok := fields[p.text]
+		if !ok {
+			b.WriteString("{" + p.text + "!MISSING}")
+			continue
+		}
+		fmt.Fprint(&b, v)
+	}
+
+	var extra []string
+	for k := range fields {
+		if !t.keys[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	return b.String()
+}
+
+// DebugT logs a message built from a template with named placeholders at
+// DEBUG level; see InfoT.
+func DebugT(tmpl string, fields Fields) {
+	logAt(DEBUG, 1, func() string { return parseMessageTemplate(tmpl).render(fields) })
+}
+
+// InfoT logs a message built from a template with named placeholders at
+// INFO level:
+//
+//	logger.InfoT("user {user} purchased {count} items", logger.Fields{"user": u, "count": n})
+//
+// Placeholders without a field are rendered as "{name!MISSING}", and
+// fields not named in the template are appended as key=value pairs. Use
+// "{{" and "}}" for literal braces. Parsed templates are cached, up to a
+// fixed number, so templates should be constant strings.
+func InfoT(tmpl string, fields Fields) {
+	logAt(INFO, 1, func() string { return parseMessageTemplate(tmpl).render(fields) })
+}
+
+// WarnT logs a message built from a template with named placeholders at
+// WARN level; see InfoT.
+func WarnT(tmpl string, fields Fields) {
+	logAt(WARN, 1, func() string { return parseMessageTemplate(tmpl).render(fields) })
+}
+
+// ErrorT logs a message built from a template with named placeholders at
+// ERROR level; see InfoT.
+func ErrorT(tmpl string, fields Fields) {
+	logAt(ERROR, 1, func() string { return parseMessageTemplate(tmpl).render(fields) })
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl   string
+		fields Fields
+		want   string
+	}{
+		{"user {user} bought {n}", Fields{"user": "ann", "n": 3}, "user ann bought 3"},
+		{"{{literal}} {x}", Fields{"x": 1}, "{literal} 1"},
+		{"missing {x}", nil, "missing {x!MISSING}"},
+		{"extra", Fields{"b": 2, "a": 1}, "extra a=1 b=2"},
+		{"open { brace", nil, "open { brace"},
+	}
+	for _, tt := range tests {
+		if got := parseMessageTemplate(tt.tmpl).render(tt.fields); got != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestMessageTemplateCacheBounded(t *testing.T) {
+	for i := 0; i < 2*maxMessageTemplates; i++ {
+		tmpl := "request " + strconv.Itoa(i) + " took {d}"
+		if got := parseMessageTemplate(tmpl).render(Fields{"d": "1s"}); got != "request "+strconv.Itoa(i)+" took 1s" {
+			t.Fatalf("render(%q) = %q", tmpl, got)
+		}
+	}
+
+	n := 0
+	messageTemplates.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	if n > maxMessageTemplates || int(messageTemplateCount.Load()) != n {
+		t.Errorf("cache holds %d templates, counted %d, limit %d", n, messageTemplateCount.Load(), maxMessageTemplates)
+	}
+}
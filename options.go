@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Option configures the behavior of InitLogger.
+type Option func(*config)
+
+// config holds the settings applied by InitLogger and reused whenever the
+// logger has to create a new file.
+type config struct {
+	openMode        OpenMode
+	fileMode        os.FileMode
+	dirMode         os.FileMode
+	enforceFileMode bool
+	enforceDirMode  bool
+	fileLock        bool
+	dateLayout      string
+	currentLink     string
+	linkFallback    LinkFallback
+	formatter       Formatter
+	binary          bool
+	diskGuard       *DiskGuard
+	maxTotalSize    int64
+	fallbacks       []io.Writer
+	probeInterval   time.Duration
+	triggerBuffer   *TriggerBuffer
+	eventIDs        bool
+	sanitize        bool
+}
+
+// defaultConfig returns the settings used when no options are given.
+func defaultConfig() config {
+	return config{
+		fileMode:  0644,
+		dirMode:   0755,
+		formatter: TextFormatter{},
+	}
+}
+
+// cfg is the configuration of the active logger.
+var cfg = defaultConfig()
+
+// WithFileMode sets the permission bits used when creating log files.
+//
+// The mode passed to the operating system is filtered by the process umask,
+// so a umask of 022 would still allow 0600 but would turn 0666 into 0644.
+// To guarantee the requested permissions regardless of the umask, the file
+// is explicitly chmod-ed after it is opened. The default is 0644.
+func WithFileMode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.fileMode = mode.Perm()
+		c.enforceFileMode = true
+	}
+}
+
+// WithDirMode sets the permission bits used when creating the directories
+// that hold the log file.
+//
+// Like WithFileMode, the requested mode is re-applied with chmod after the
+// directories are created so the umask cannot widen or narrow it. Only
+// directories created by the logger are affected; existing ones are left
+// untouched. The default is 0755.
+func WithDirMode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.dirMode = mode.Perm()
+		c.enforceDirMode = true
+	}
+}
+
+// WithFileLock enables advisory locking for log files shared between
+// several processes.
+//
+// Each write holds a shared lock and rotation or reopen holds an exclusive
+// one, taken on a sidecar file named after the log with a ".lock" suffix
+// (and any {date} and {time} placeholders removed). Before writing, a
+// process checks whether the log has been rotated by another process and
+// reopens the path if so, which lets every process share the rotation
+// responsibility. On Linux, the BSDs and macOS the lock
+// is implemented with flock(2); elsewhere a lock file created exclusively
+// is used instead, which serializes writes across processes.
+func WithFileLock() Option {
+	return func(c *config) {
+		c.fileLock = true
+	}
+}
+
+// WithFormatter sets the formatter used to render entries. The default is
+// TextFormatter.
+func WithFormatter(f Formatter) Option {
+	return func(c *config) {
+		if f != nil {
+			c.formatter = f
+		}
+	}
+}
+
+// OpenMode controls what happens to an existing log file when the logger
+// opens it.
+type OpenMode int
+
+// Available open modes.
+//
+// OpenAppend keeps the existing contents and appends new entries.
+// OpenTruncate discards the existing contents, giving a fresh log per run.
+// OpenExclusive refuses to open a file that already exists.
+const (
+	OpenAppend OpenMode = iota
+	OpenTruncate
+	OpenExclusive
+)
+
+// flags returns the os.OpenFile flags corresponding to the mode.
+func (m OpenMode) flags() int {
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	switch m {
+	case OpenTruncate:
+		flags |= os.O_TRUNC
+	case OpenExclusive:
+		flags |= os.O_EXCL
+	}
+	return flags
+}
+
+// WithOpenMode sets how InitLogger treats an existing log file.
+//
+// With OpenExclusive, InitLogger fails if the file already exists and the
+// returned error satisfies errors.Is(err, fs.ErrExist). The mode only
+// applies to the initial open; files created after a rotation are always
+// new files. The default is OpenAppend.
+func WithOpenMode(mode OpenMode) Option {
+	return func(c *config) {
+		c.openMode = mode
+	}
+}
+
+// validate reports an error if the configuration contains invalid values.
+func (c *config) validate() error {
+	switch c.openMode {
+	case OpenAppend, OpenTruncate, OpenExclusive:
+	default:
+		return fmt.Errorf("invalid open mode: %d", c.openMode)
+	}
+	if v, ok := c.formatter.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+	if c.dateLayout != "" {
+		if err := validateDateLayout(c.dateLayout); err != nil {
+			return err
+		}
+	}
+	if c.maxTotalSize < 0 {
+		return fmt.Errorf("invalid maximum total size: %d", c.maxTotalSize)
+	}
+	return nil
+}
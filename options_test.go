@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestFileAndDirModes checks the permissions of the files and directories
+// created by the logger, including the file opened after a rotation. The
+// group-writable modes would be narrowed by the usual umask of 022 if they
+// were not enforced.
+func TestFileAndDirModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	dir := initTestLogger(t, "a/b/app.log", WithFileMode(0660), WithDirMode(0770))
+	path := filepath.Join(dir, "a", "b", "app.log")
+
+	Info("before")
+	backup, err := Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		want os.FileMode
+	}{
+		{filepath.Join(dir, "a"), os.ModeDir | 0770},
+		{filepath.Join(dir, "a", "b"), os.ModeDir | 0770},
+		{path, 0660},
+		{backup, 0660},
+	} {
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != tt.want {
+			t.Errorf("%s has mode %v, want %v", tt.path, info.Mode(), tt.want)
+		}
+	}
+
+	// Existing directories are left alone.
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() == 0770 {
+		t.Errorf("existing directory %s was changed to %v", dir, info.Mode())
+	}
+}
+
+func TestOpenModes(t *testing.T) {
+	for _, tt := range []struct {
+		mode OpenMode
+		want []string
+	}{
+		{OpenAppend, []string{"old entry", " - new"}},
+		{OpenTruncate, []string{" - new"}},
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		if err := os.WriteFile(path, []byte("old entry\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := InitLogger(path, WithOpenMode(tt.mode)); err != nil {
+			t.Fatalf("mode %d: InitLogger: %v", tt.mode, err)
+		}
+		Info("new")
+		Close()
+
+		lines := readLines(t, path)
+		if len(lines) != len(tt.want) {
+			t.Errorf("mode %d: file holds %q, want %d lines", tt.mode, lines, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.HasSuffix(lines[i], want) {
+				t.Errorf("mode %d: line %d = %q, want suffix %q", tt.mode, i, lines[i], want)
+			}
+		}
+	}
+}
+
+func TestOpenExclusive(t *testing.T) {
+	dir := initTestLogger(t, "app.log", WithOpenMode(OpenExclusive))
+	path := filepath.Join(dir, "app.log")
+	Info("before rotation")
+
+	// New files of a rotation are created even though the mode is
+	// exclusive.
+	if _, err := Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	Info("first run")
+	Close()
+
+	err := InitLogger(path, WithOpenMode(OpenExclusive))
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("InitLogger on an existing file = %v, want fs.ErrExist", err)
+	}
+	lines := readLines(t, path)
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], " - first run") {
+		t.Errorf("existing log was modified: %q", lines)
+	}
+
+	if err := InitLogger(path, WithOpenMode(OpenMode(9))); err == nil {
+		t.Error("InitLogger accepted an invalid open mode")
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/73ddy-io/logger/otlp
+
+go 1.22.0
+
+require github.com/73ddy-io/logger v0.0.0-00010101000000-000000000000
+
+replace github.com/73ddy-io/logger => ../
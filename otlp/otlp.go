@@ -0,0 +1,269 @@
+// Package otlp exports log entries to an OpenTelemetry collector using
+// OTLP over HTTP with protobuf encoding.
+//
+// It is a module of its own, so that programs not exporting to a
+// collector do not depend on it, and encodes the few OTLP messages it
+// sends itself instead of depending on the OpenTelemetry SDK. Entries are
+// batched with logger.Batcher and retried with logger.RetryPolicy; batches
+// that still fail are counted in logger.Stats.BatchDropped.
+//
+//	e, err := otlp.New(otlp.Config{
+//		Endpoint:    "http://otel-collector:4318/v1/logs",
+//		ServiceName: "billing",
+//	})
+//	logger.AddEntryOutput("otlp", e, logger.OutputConfig{})
+//	defer e.Close(context.Background())
+//
+// The exporter receives the entries as logged, whatever the format of the
+// log file. The trace context attached with logger.ContextWithTrace and
+// logged with logger.InfoCtx and the other Ctx functions becomes the
+// trace and span IDs of the records.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/73ddy-io/logger"
+)
+
+// Config describes the collector records are exported to.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP logs endpoint, by default
+	// "http://localhost:4318/v1/logs".
+	Endpoint string
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string
+
+	// ServiceName is the service.name resource attribute. It defaults to
+	// the name of the executable.
+	ServiceName string
+
+	// Resource holds further resource attributes. host.name is set to the
+	// host name unless given here.
+	Resource map[string]string
+
+	// MaxCount, MaxBytes and MaxWait limit a batch as in
+	// logger.BatchConfig. If all are zero, batches of up to 512 entries
+	// are exported at least once a second.
+	MaxCount int
+	MaxBytes int
+	MaxWait  time.Duration
+
+	// Retry controls retrying failed exports; logger.DefaultRetryPolicy if
+	// nil. Only network errors and the statuses the OTLP specification
+	// marks as retryable are retried.
+	Retry *logger.RetryPolicy
+
+	// Timeout bounds a single export request. The default is 10s.
+	Timeout time.Duration
+
+	// HTTPClient is used for requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Exporter sends entries to a collector. It implements logger.EntryWriter
+// for logger.AddEntryOutput, and entries can also be added directly with
+// Add.
+type Exporter struct {
+	cfg      Config
+	resource []byte
+	scope    []byte
+	b        *logger.Batcher
+}
+
+// New validates cfg and returns an Exporter.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("otlp: invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.ServiceName == "" {
+		if exe, err := os.Executable(); err == nil {
+			cfg.ServiceName = exe[strings.LastIndexAny(exe, `/\`)+1:]
+		}
+	}
+	if cfg.MaxCount <= 0 && cfg.MaxBytes <= 0 && cfg.MaxWait <= 0 {
+		cfg.MaxCount = 512
+		cfg.MaxWait = time.Second
+	}
+	if cfg.Retry == nil {
+		cfg.Retry = &logger.DefaultRetryPolicy
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	x := &Exporter{cfg: cfg, resource: encodeResource(cfg), scope: encodeScope()}
+	x.b = logger.NewBatcher(logger.BatchConfig{
+		MaxCount: cfg.MaxCount,
+		MaxBytes: cfg.MaxBytes,
+		MaxWait:  cfg.MaxWait,
+		Flush:    x.export,
+		Retry:    cfg.Retry,
+	})
+	return x, nil
+}
+
+// WriteEntry implements logger.EntryWriter, queueing e to be exported
+// with the next batch.
+func (x *Exporter) WriteEntry(e logger.Entry) error {
+	return x.b.Add(e)
+}
+
+// Add queues e to be exported with the next batch.
+func (x *Exporter) Add(e logger.Entry) error {
+	return x.b.Add(e)
+}
+
+// Close exports the pending entries, waiting until they are exported or
+// ctx is done.
+func (x *Exporter) Close(ctx context.Context) error {
+	return x.b.Close(ctx)
+}
+
+// export sends batch in one ExportLogsServiceRequest.
+func (x *Exporter) export(batch []logger.Entry) error {
+	body := x.encodeRequest(batch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t := logger.CurrentClock().AfterFunc(x.cfg.Timeout, cancel)
+	defer t.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, x.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return logger.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range x.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := x.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("otlp: export failed: %s", resp.Status)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return err
+	}
+	if len(msg) > 0 && utf8.Valid(msg) {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(msg)))
+	}
+	return logger.Permanent(err)
+}
+
+// encodeRequest encodes an ExportLogsServiceRequest holding batch.
+func (x *Exporter) encodeRequest(batch []logger.Entry) []byte {
+	var scopeLogs []byte
+	scopeLogs = appendBytes(scopeLogs, 1, x.scope)
+	for i := range batch {
+		scopeLogs = appendBytes(scopeLogs, 2, encodeRecord(&batch[i]))
+	}
+
+	var resourceLogs []byte
+	resourceLogs = appendBytes(resourceLogs, 1, x.resource)
+	resourceLogs = appendBytes(resourceLogs, 2, scopeLogs)
+
+	return appendBytes(nil, 1, resourceLogs)
+}
+
+// severities maps the levels to OTLP severity numbers and texts, indexed
+// by level - logger.DEBUG.
+var severities = [logger.ERROR - logger.DEBUG + 1]struct {
+	number uint64
+	text   string
+}{
+	{5, "DEBUG"},
+	{9, "INFO"},
+	{13, "WARN"},
+	{17, "ERROR"},
+}
+
+// encodeRecord encodes e as a LogRecord.
+func encodeRecord(e *logger.Entry) []byte {
+	level := e.Level
+	if level < logger.DEBUG {
+		level = logger.DEBUG
+	} else if level > logger.ERROR {
+		level = logger.ERROR
+	}
+	sev := severities[level-logger.DEBUG]
+
+	var body []byte
+	body = appendString(body, 1, e.Message)
+
+	var r []byte
+	r = appendFixed64(r, 1, uint64(e.Time.UnixNano()))
+	r = appendVarint(r, 2, sev.number)
+	r = appendString(r, 3, sev.text)
+	r = appendBytes(r, 5, body)
+	r = appendStringAttr(r, 6, "code.filepath", e.File)
+	r = appendIntAttr(r, 6, "code.lineno", int64(e.Line))
+	r = appendStringAttr(r, 6, "code.function", e.Func)
+	r = appendIntAttr(r, 6, "process.pid", int64(e.PID))
+	if e.ID != "" {
+		r = appendStringAttr(r, 6, "log.record.uid", e.ID)
+	}
+	if traceID, err := hex.DecodeString(e.TraceID); err == nil && len(traceID) == 16 {
+		r = appendBytes(r, 9, traceID)
+	}
+	if spanID, err := hex.DecodeString(e.SpanID); err == nil && len(spanID) == 8 {
+		r = appendBytes(r, 10, spanID)
+	}
+	r = appendFixed64(r, 11, uint64(logger.CurrentClock().Now().UnixNano()))
+	return r
+}
+
+// encodeResource encodes the Resource described by cfg.
+func encodeResource(cfg Config) []byte {
+	attrs := map[string]string{"service.name": cfg.ServiceName}
+	if host, err := os.Hostname(); err == nil {
+		attrs["host.name"] = host
+	}
+	for k, v := range cfg.Resource {
+		attrs[k] = v
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var res []byte
+	for _, k := range keys {
+		res = appendStringAttr(res, 1, k, attrs[k])
+	}
+	return res
+}
+
+// encodeScope encodes the InstrumentationScope naming the logger.
+func encodeScope() []byte {
+	return appendString(nil, 1, "github.com/73ddy-io/logger")
+}
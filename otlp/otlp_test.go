@@ -0,0 +1,281 @@
+package otlp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/73ddy-io/logger"
+)
+
+// field is a decoded protobuf field.
+type field struct {
+	num int
+	v   uint64
+	b   []byte
+}
+
+// decodeFields splits a protobuf message into its fields.
+func decodeFields(t *testing.T, b []byte) []field {
+	t.Helper()
+	var fields []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("bad field key")
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			f.v, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("bad varint in field %d", f.num)
+			}
+			b = b[n:]
+		case wireFixed64:
+			f.v = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatalf("bad length of field %d", f.num)
+			}
+			f.b = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// record is a LogRecord as received by fakeCollector.
+type record struct {
+	time         time.Time
+	severity     uint64
+	severityText string
+	body         string
+	attrs        map[string]string
+	traceID      string
+	spanID       string
+	observed     time.Time
+}
+
+// decodeAttr decodes a KeyValue with a string or int value.
+func decodeAttr(t *testing.T, b []byte) (string, string) {
+	var key, value string
+	for _, f := range decodeFields(t, b) {
+		switch f.num {
+		case 1:
+			key = string(f.b)
+		case 2:
+			for _, v := range decodeFields(t, f.b) {
+				switch v.num {
+				case 1:
+					value = string(v.b)
+				case 3:
+					value = fmt.Sprint(int64(v.v))
+				}
+			}
+		}
+	}
+	return key, value
+}
+
+// fakeCollector is an OTLP/HTTP logs endpoint decoding the requests it
+// receives. It rejects the first fails requests with 503.
+type fakeCollector struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	fails    int
+	requests int
+	resource map[string]string
+	records  []record
+}
+
+func (c *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/x-protobuf" || r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if c.fails > 0 {
+		c.fails--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	for _, rl := range decodeFields(c.t, body) {
+		for _, f := range decodeFields(c.t, rl.b) {
+			switch f.num {
+			case 1:
+				c.resource = make(map[string]string)
+				for _, a := range decodeFields(c.t, f.b) {
+					k, v := decodeAttr(c.t, a.b)
+					c.resource[k] = v
+				}
+			case 2:
+				for _, sf := range decodeFields(c.t, f.b) {
+					if sf.num == 2 {
+						c.records = append(c.records, c.decodeRecord(sf.b))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *fakeCollector) decodeRecord(b []byte) record {
+	r := record{attrs: make(map[string]string)}
+	for _, f := range decodeFields(c.t, b) {
+		switch f.num {
+		case 1:
+			r.time = time.Unix(0, int64(f.v))
+		case 2:
+			r.severity = f.v
+		case 3:
+			r.severityText = string(f.b)
+		case 5:
+			for _, v := range decodeFields(c.t, f.b) {
+				if v.num == 1 {
+					r.body = string(v.b)
+				}
+			}
+		case 6:
+			k, v := decodeAttr(c.t, f.b)
+			r.attrs[k] = v
+		case 9:
+			r.traceID = hex.EncodeToString(f.b)
+		case 10:
+			r.spanID = hex.EncodeToString(f.b)
+		case 11:
+			r.observed = time.Unix(0, int64(f.v))
+		}
+	}
+	return r
+}
+
+func newTestExporter(t *testing.T, c *fakeCollector) *Exporter {
+	t.Helper()
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	x, err := New(Config{
+		Endpoint:    srv.URL + "/v1/logs",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		ServiceName: "billing",
+		Resource:    map[string]string{"deployment.environment": "test"},
+		MaxCount:    2,
+		Retry:       &logger.RetryPolicy{InitialDelay: time.Millisecond, MaxAttempts: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func TestExportMapsEntries(t *testing.T) {
+	c := &fakeCollector{t: t}
+	x := newTestExporter(t, c)
+
+	if err := logger.InitLogger(filepath.Join(t.TempDir(), "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	if err := logger.AddEntryOutput("otlp", x, logger.OutputConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const traceID, spanID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	ctx := logger.ContextWithTrace(context.Background(), traceID, spanID)
+	logger.InfoCtx(ctx, "charged %d cents", 250)
+	logger.Error("card declined")
+
+	logger.RemoveOutput("otlp")
+	if err := x.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resource["service.name"] != "billing" || c.resource["deployment.environment"] != "test" || c.resource["host.name"] == "" {
+		t.Errorf("resource = %v", c.resource)
+	}
+	if len(c.records) != 2 {
+		t.Fatalf("received %d records, want 2", len(c.records))
+	}
+
+	info, failure := c.records[0], c.records[1]
+	if info.body != "charged 250 cents" || info.severity != 9 || info.severityText != "INFO" {
+		t.Errorf("INFO record = %+v", info)
+	}
+	if info.traceID != traceID || info.spanID != spanID {
+		t.Errorf("trace context = %s/%s, want %s/%s", info.traceID, info.spanID, traceID, spanID)
+	}
+	if info.attrs["code.function"] != "TestExportMapsEntries" || info.attrs["code.filepath"] != "otlp_test.go" || info.attrs["code.lineno"] == "" {
+		t.Errorf("code attributes = %v", info.attrs)
+	}
+	if info.time.IsZero() || info.observed.Before(info.time) {
+		t.Errorf("time = %v, observed = %v", info.time, info.observed)
+	}
+	if failure.body != "card declined" || failure.severity != 17 || failure.severityText != "ERROR" {
+		t.Errorf("ERROR record = %+v", failure)
+	}
+	if failure.traceID != "" || failure.spanID != "" {
+		t.Errorf("entry without trace context exported trace %q span %q", failure.traceID, failure.spanID)
+	}
+}
+
+func TestExportRetries(t *testing.T) {
+	c := &fakeCollector{t: t, fails: 2}
+	x := newTestExporter(t, c)
+	before := logger.CurrentStats()
+
+	x.Add(logger.Entry{Time: time.Now(), Level: logger.WARN, Message: "slow"})
+	if err := x.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests != 3 || len(c.records) != 1 {
+		t.Errorf("%d requests delivered %d records, want 3 and 1", c.requests, len(c.records))
+	}
+	if n := logger.CurrentStats().BatchDropped - before.BatchDropped; n != 0 {
+		t.Errorf("%d entries dropped", n)
+	}
+}
+
+func TestExportDropsAfterPermanentFailure(t *testing.T) {
+	c := &fakeCollector{t: t}
+	x := newTestExporter(t, c)
+	x.cfg.Headers = nil // the collector rejects the request with 400
+	logger.SetErrorHandler(func(error) {})
+	defer logger.SetErrorHandler(nil)
+	before := logger.CurrentStats()
+
+	x.Add(logger.Entry{Time: time.Now(), Level: logger.INFO, Message: "rejected"})
+	x.Close(context.Background())
+
+	if c.requests != 1 {
+		t.Errorf("%d requests, want 1 without retries", c.requests)
+	}
+	if n := logger.CurrentStats().BatchDropped - before.BatchDropped; n != 1 {
+		t.Errorf("%d entries dropped, want 1", n)
+	}
+}
@@ -0,0 +1,71 @@
+package otlp
+
+import "encoding/binary"
+
+// The protobuf encoding below covers the messages of
+// opentelemetry/proto/collector/logs/v1/logs_service.proto that the
+// exporter sends.
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// appendKey appends the key of field num with wire type wt.
+func appendKey(b []byte, num int, wt int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(wt))
+}
+
+// appendVarint appends an integer field. Zero values are omitted, as
+// proto3 does.
+func appendVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendKey(b, num, wireVarint), v)
+}
+
+// appendFixed64 appends a fixed64 field.
+func appendFixed64(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendKey(b, num, wireFixed64), v)
+}
+
+// appendBytes appends a length-delimited field: a string, bytes or an
+// embedded message.
+func appendBytes(b []byte, num int, p []byte) []byte {
+	b = binary.AppendUvarint(appendKey(b, num, wireBytes), uint64(len(p)))
+	return append(b, p...)
+}
+
+// appendString appends a string field.
+func appendString(b []byte, num int, s string) []byte {
+	b = binary.AppendUvarint(appendKey(b, num, wireBytes), uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendStringAttr appends a KeyValue with a string AnyValue as field num.
+func appendStringAttr(b []byte, num int, key, value string) []byte {
+	var any []byte
+	any = appendString(any, 1, value)
+	return appendAttr(b, num, key, any)
+}
+
+// appendIntAttr appends a KeyValue with an int AnyValue as field num.
+func appendIntAttr(b []byte, num int, key string, value int64) []byte {
+	var any []byte
+	any = binary.AppendUvarint(appendKey(any, 3, wireVarint), uint64(value))
+	return appendAttr(b, num, key, any)
+}
+
+// appendAttr appends a KeyValue holding the encoded AnyValue any.
+func appendAttr(b []byte, num int, key string, any []byte) []byte {
+	var kv []byte
+	kv = appendString(kv, 1, key)
+	kv = appendBytes(kv, 2, any)
+	return appendBytes(b, num, kv)
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// SetOutput redirects the logger to the file filename.
+//
+// The new file is opened with the options given to InitLogger before the
+// switch, and the previous output is closed only after the new one is in
+// place, so concurrent log calls write to exactly one of them. If the
+// logger has not been initialized or has been closed, SetOutput
+// initializes it with the default options.
+func SetOutput(filename string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return openOutput(filename, cfg.openMode)
+}
+
+// SetWriter redirects the logger to w, closing the previous log file.
+//
+// Features tied to files, such as date layouts, the current link and file
+// locking, are inactive while writing to w. The logger does not close w.
+func SetWriter(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	err := closeOutput()
+	nameTemplate = nil
+	logPath = ""
+	setWriter(w)
+	return err
+}
+
+// openOutput opens filename according to mode and makes it the active
+// output, closing the previous one. The caller must hold mu.
+func openOutput(filename string, mode OpenMode) error {
+	tmpl, err := parseFilenameTemplate(filename)
+	if err != nil {
+		return err
+	}
+
+	now := clockNow()
+	path := resolveLogPath(tmpl, now)
+
+	f, err := openLogFile(path, mode)
+	if err != nil {
+		return err
+	}
+
+	var l *fileLock
+	if cfg.fileLock {
+		l, err = newFileLock(tmpl.stable() + ".lock")
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+	}
+
+	prevErr := closeOutput()
+	writeFileHeader(f)
+	logFile = f
+	logPath = path
+	nameTemplate = tmpl
+	lock = l
+	dateChecked = now.Unix()
+	dateDir = dateKey(now)
+	setWriter(f)
+	updateCurrentLink(path)
+	enforceTotalSize()
+
+	if prevErr != nil {
+		logInternal(WARN, fmt.Sprintf("failed to close previous log output: %v", prevErr))
+	}
+	return nil
+}
+
+// setWriter makes w the destination of the logger and resumes accepting
+// entries. The caller must hold mu.
+func setWriter(w io.Writer) {
+	if logger == nil {
+		logger = log.New(w, "", 0)
+	} else {
+		logger.SetOutput(w)
+	}
+	closed = false
+}
+
+// closeOutput closes the active log file and its lock, if any. The caller
+// must hold mu.
+func closeOutput() error {
+	var errs []error
+	if lock != nil {
+		if err := lock.close(); err != nil {
+			errs = append(errs, err)
+		}
+		lock = nil
+	}
+	if logFile != nil {
+		if err := logFile.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		logFile = nil
+	}
+	return errors.Join(errs...)
+}
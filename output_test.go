@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// logConcurrently logs "entry g/i" messages from goroutines goroutines,
+// entries each. The actions are called in turn while the logging is under
+// way, spread evenly over it. It returns when all goroutines are done.
+func logConcurrently(goroutines, entries int, actions ...func()) {
+	var wg sync.WaitGroup
+	var logged atomic.Int64
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				Info("entry %d/%d", g, i)
+				logged.Add(1)
+			}
+		}(g)
+	}
+	total := int64(goroutines * entries)
+	for i, action := range actions {
+		for logged.Load() < total*int64(i+1)/int64(len(actions)+1) {
+			runtime.Gosched()
+		}
+		action()
+	}
+	wg.Wait()
+}
+
+// checkEntriesOnce checks that every message logged by logConcurrently
+// appears exactly once in the files at paths.
+func checkEntriesOnce(t *testing.T, goroutines, entries int, paths ...string) {
+	t.Helper()
+	seen := make(map[string]int)
+	for _, path := range paths {
+		for _, line := range readLines(t, path) {
+			if line == "" {
+				continue
+			}
+			e, err := ParseLine(line)
+			if err != nil {
+				t.Fatalf("%s: ParseLine(%q): %v", path, line, err)
+			}
+			seen[e.Message]++
+		}
+	}
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < entries; i++ {
+			if msg := fmt.Sprintf("entry %d/%d", g, i); seen[msg] != 1 {
+				t.Errorf("%q written %d times", msg, seen[msg])
+			}
+		}
+	}
+}
+
+// TestSetOutputUnderLoad swaps the output twice while goroutines are
+// logging and checks that every entry went to exactly one of the files.
+func TestSetOutputUnderLoad(t *testing.T) {
+	dir := initTestLogger(t, "a.log")
+
+	const goroutines, entries = 8, 1000
+	swap := func(name string) func() {
+		return func() {
+			if err := SetOutput(filepath.Join(dir, name)); err != nil {
+				t.Errorf("SetOutput(%s): %v", name, err)
+			}
+		}
+	}
+	logConcurrently(goroutines, entries, swap("b.log"), swap("c.log"))
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, name := range []string{"a.log", "b.log", "c.log"} {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	checkEntriesOnce(t, goroutines, entries, paths...)
+}
@@ -0,0 +1,333 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OutputConfig configures an output added with AddOutput.
+type OutputConfig struct {
+	// QueueSize is the number of entries buffered for the output. Entries
+	// logged while the queue is full are dropped. It defaults to 1024.
+	QueueSize int
+
+	// QuarantineAfter is the number of consecutive failed writes after
+	// which the output is quarantined. It defaults to 5.
+	QuarantineAfter int
+
+	// ProbeInterval is how often a quarantined output is tried again. It
+	// defaults to 30 seconds.
+	ProbeInterval time.Duration
+
+	// DrainTimeout bounds how long Close waits for the queued entries to
+	// be written. Entries still queued then are dropped. It defaults to
+	// 5 seconds.
+	DrainTimeout time.Duration
+}
+
+// OutputStats is a snapshot of the counters of an output added with
+// AddOutput.
+type OutputStats struct {
+	Name string
+
+	// Written, Failed and Dropped count the entries written, the entries
+	// whose write failed or panicked, and the entries discarded because
+	// the queue was full or the output was quarantined.
+	Written uint64
+	Failed  uint64
+	Dropped uint64
+
+	Quarantined bool
+}
+
+// EntryWriter is implemented by outputs that take entries rather than
+// formatted records, such as exporters to log collectors; see
+// AddEntryOutput.
+type EntryWriter interface {
+	WriteEntry(e Entry) error
+}
+
+// extraOutput is an output receiving a copy of every entry on its own
+// goroutine. Exactly one of w and ew is set.
+type extraOutput struct {
+	name string
+	w    io.Writer
+	ew   EntryWriter
+	cfg  OutputConfig
+
+	queue   chan queuedRecord
+	pending atomic.Int64
+	idle    chan struct{}
+	done    chan struct{}
+
+	written     atomic.Uint64
+	failed      atomic.Uint64
+	dropped     atomic.Uint64
+	quarantined atomic.Bool
+
+	// Only used by the output's goroutine.
+	failures int
+	probeAt  time.Time
+}
+
+// queuedRecord is a record waiting to be written to an output. entries
+// holds the entries the record was encoded from, for EntryWriter outputs.
+type queuedRecord struct {
+	rec     []byte
+	entries []Entry
+}
+
+// extraOutputs are the outputs added with AddOutput. It is guarded by mu.
+var extraOutputs []*extraOutput
+
+// AddOutput sends a copy of every entry, in the configured format, to w
+// in addition to the log file, for example to also log to os.Stdout.
+//
+// Every output is written on its own goroutine from a bounded queue, so an
+// output that fails, panics or blocks never delays or breaks the others.
+// Entries reach each output in the order they were logged. After
+// QuarantineAfter consecutive failures the output is quarantined: entries
+// for it are dropped, except for a probe every ProbeInterval, until a write
+// succeeds again. Failures are reported to the error handler when an
+// output starts failing and when it is quarantined.
+//
+// The name identifies the output in errors and OutputStatistics; adding
+// an output with a name already in use fails. Close waits up to
+// DrainTimeout for the queued entries to be written, but outputs stay
+// attached across InitLogger and Close until removed with RemoveOutput.
+func AddOutput(name string, w io.Writer, c OutputConfig) error {
+	return addOutput(&extraOutput{name: name, w: w}, c)
+}
+
+// AddEntryOutput is like AddOutput but passes every entry to w as it was
+// logged instead of formatting it, so that w can map the fields of the
+// entry, including the trace context of InfoCtx and the other Ctx
+// functions, without parsing log lines. A record holding several entries,
+// such as a Group, is handled as one: the output fails if any of its
+// entries fail.
+func AddEntryOutput(name string, w EntryWriter, c OutputConfig) error {
+	return addOutput(&extraOutput{name: name, ew: w}, c)
+}
+
+// addOutput applies the defaults of c and attaches o.
+func addOutput(o *extraOutput, c OutputConfig) error {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1024
+	}
+	if c.QuarantineAfter <= 0 {
+		c.QuarantineAfter = 5
+	}
+	if c.ProbeInterval <= 0 {
+		c.ProbeInterval = 30 * time.Second
+	}
+	if c.DrainTimeout <= 0 {
+		c.DrainTimeout = 5 * time.Second
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, other := range extraOutputs {
+		if other.name == o.name {
+			return fmt.Errorf("logger: output %q already exists", o.name)
+		}
+	}
+	o.cfg = c
+	o.queue = make(chan queuedRecord, c.QueueSize)
+	o.idle = make(chan struct{}, 1)
+	o.done = make(chan struct{})
+	extraOutputs = append(extraOutputs, o)
+	go o.run()
+	return nil
+}
+
+// RemoveOutput detaches the output added under name after writing the
+// entries still queued for it. It reports whether the output existed.
+func RemoveOutput(name string) bool {
+	mu.Lock()
+	var o *extraOutput
+	for i, other := range extraOutputs {
+		if other.name == name {
+			o = other
+			extraOutputs = append(extraOutputs[:i:i], extraOutputs[i+1:]...)
+			break
+		}
+	}
+	mu.Unlock()
+
+	if o == nil {
+		return false
+	}
+	close(o.queue)
+	<-o.done
+	return true
+}
+
+// OutputStatistics returns the counters of every output added with
+// AddOutput, in the order they were added.
+func OutputStatistics() []OutputStats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats := make([]OutputStats, 0, len(extraOutputs))
+	for _, o := range extraOutputs {
+		stats = append(stats, OutputStats{
+			Name:        o.name,
+			Written:     o.written.Load(),
+			Failed:      o.failed.Load(),
+			Dropped:     o.dropped.Load(),
+			Quarantined: o.quarantined.Load(),
+		})
+	}
+	return stats
+}
+
+// fanOut queues rec, encoded from es, for every added output without
+// blocking. The caller must hold mu.
+func fanOut(rec []byte, es []Entry) {
+	for _, o := range extraOutputs {
+		q := queuedRecord{rec: rec}
+		if o.ew != nil {
+			q.entries = append([]Entry(nil), es...)
+		}
+		o.pending.Add(1)
+		select {
+		case o.queue <- q:
+		default:
+			o.handled()
+			o.dropped.Add(1)
+		}
+	}
+}
+
+// handled records that a queued record has been handled.
+func (o *extraOutput) handled() {
+	if o.pending.Add(-1) == 0 {
+		select {
+		case o.idle <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// drainOutputs waits until the entries queued for every added output have
+// been handled, or its DrainTimeout has passed. The outputs are drained in
+// parallel, so a blocked output delays Close by its timeout at most. The
+// caller must hold mu.
+func drainOutputs() error {
+	errs := make([]error, len(extraOutputs))
+	var wg sync.WaitGroup
+	for i, o := range extraOutputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = o.drain()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// drain waits for the queue of o to empty. On timeout the records still
+// queued are discarded and counted as dropped.
+func (o *extraOutput) drain() error {
+	t := newClockTimer(o.cfg.DrainTimeout)
+	defer t.Stop()
+
+	for o.pending.Load() > 0 {
+		select {
+		case <-o.idle:
+		case <-t.C():
+			n := o.discardQueued()
+			return fmt.Errorf("logger: output %q did not drain within %v, dropped %d queued entries", o.name, o.cfg.DrainTimeout, n)
+		}
+	}
+	return nil
+}
+
+// discardQueued removes the records waiting in the queue and returns how
+// many there were.
+func (o *extraOutput) discardQueued() int {
+	n := 0
+	for {
+		select {
+		case _, ok := <-o.queue:
+			if !ok {
+				return n
+			}
+			n++
+			o.dropped.Add(1)
+			o.handled()
+		default:
+			return n
+		}
+	}
+}
+
+// run writes the queued records until the queue is closed.
+func (o *extraOutput) run() {
+	defer close(o.done)
+
+	for q := range o.queue {
+		o.handle(q)
+		o.handled()
+	}
+}
+
+// handle writes q unless the output is quarantined and not due for a
+// probe, and updates the health of the output.
+func (o *extraOutput) handle(q queuedRecord) {
+	now := clockNow()
+	if o.quarantined.Load() && now.Before(o.probeAt) {
+		o.dropped.Add(1)
+		return
+	}
+
+	if err := o.write(q); err != nil {
+		o.failed.Add(1)
+		o.failures++
+		switch {
+		case o.quarantined.Load():
+			o.probeAt = now.Add(o.cfg.ProbeInterval)
+		case o.failures >= o.cfg.QuarantineAfter:
+			o.quarantined.Store(true)
+			o.probeAt = now.Add(o.cfg.ProbeInterval)
+			reportError(fmt.Errorf("logger: output %q quarantined after %d consecutive failures: %w", o.name, o.failures, err))
+		case o.failures == 1:
+			reportError(fmt.Errorf("logger: output %q: %w", o.name, err))
+		}
+		return
+	}
+
+	o.written.Add(1)
+	o.failures = 0
+	o.quarantined.Store(false)
+}
+
+// write writes q to the output, turning a panic into an error.
+func (o *extraOutput) write(q queuedRecord) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("write panicked: %v", p)
+		}
+	}()
+
+	if o.ew != nil {
+		var errs []error
+		for _, e := range q.entries {
+			if err := o.ew.WriteEntry(e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	n, err := o.w.Write(q.rec)
+	if err == nil && n < len(q.rec) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
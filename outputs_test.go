@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks every write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestCloseBoundsBlockedOutput(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 5, 3, 12, 0, 0, 0, time.Local))
+	initTestLogger(t, "app.log")
+
+	w := blockingWriter{release: make(chan struct{})}
+	if err := AddOutput("stuck", w, OutputConfig{DrainTimeout: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveOutput("stuck")
+	defer close(w.release)
+
+	for i := 0; i < 3; i++ {
+		Info("entry %d", i)
+	}
+
+	closed := make(chan error)
+	go func() { closed <- Close() }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+
+	select {
+	case err := <-closed:
+		if err == nil || !strings.Contains(err.Error(), `output "stuck" did not drain`) {
+			t.Errorf("Close() = %v, want a drain timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a stuck output")
+	}
+
+	stats := OutputStatistics()
+	if len(stats) != 1 || stats[0].Dropped != 2 {
+		t.Errorf("OutputStatistics() = %+v, want the 2 queued entries dropped", stats)
+	}
+}
+
+// gatedWriter counts the lines written to it, blocking every write until
+// gate is closed.
+type gatedWriter struct {
+	gate  chan struct{}
+	mu    sync.Mutex
+	lines int
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines += strings.Count(string(p), "\n")
+	return len(p), nil
+}
+
+// TestCloseDrainsQueuedEntries queues thousands of entries behind a
+// blocked output, calls Close while they are still queued and checks that
+// none are lost, in the output or in the file.
+func TestCloseDrainsQueuedEntries(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 5, 3, 12, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	const goroutines, entries = 10, 500
+	w := &gatedWriter{gate: make(chan struct{})}
+	if err := AddOutput("gated", w, OutputConfig{QueueSize: goroutines * entries}); err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveOutput("gated")
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				Info("entry %d/%d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	closed := make(chan error)
+	go func() { closed <- Close() }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(w.gate)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	if err := Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lines != goroutines*entries {
+		t.Errorf("output received %d entries, want %d", w.lines, goroutines*entries)
+	}
+	if n := len(readLines(t, filepath.Join(dir, "app.log"))); n != goroutines*entries {
+		t.Errorf("file holds %d entries, want %d", n, goroutines*entries)
+	}
+	if stats := OutputStatistics(); len(stats) != 1 || stats[0].Dropped != 0 {
+		t.Errorf("OutputStatistics() = %+v, want nothing dropped", stats)
+	}
+}
+
+// entryRecorder is an EntryWriter collecting the entries it receives.
+type entryRecorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (r *entryRecorder) WriteEntry(e Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func TestEntryOutputReceivesEntries(t *testing.T) {
+	initTestLogger(t, "app.log", WithFormatter(CSVFormatter{}))
+	r := &entryRecorder{}
+	if err := AddEntryOutput("entries", r, OutputConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	Warn("disk at %d%%", 91)
+	g := BeginGroup()
+	g.Info("first")
+	g.Info("second")
+	g.Commit()
+	RemoveOutput("entries")
+
+	var got []string
+	for _, e := range r.entries {
+		got = append(got, levelName(e.Level)+" "+e.Message)
+		if e.Func != "TestEntryOutputReceivesEntries" {
+			t.Errorf("entry %q attributed to %q", e.Message, e.Func)
+		}
+	}
+	if want := "WARN disk at 91%|INFO first|INFO second"; strings.Join(got, "|") != want {
+		t.Errorf("received %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLineSize is the longest line the Scanner accepts.
+const maxLineSize = 1 << 20
+
+// ErrInvalidLine is wrapped by the errors returned for lines that do not
+// follow the default text format.
+var ErrInvalidLine = errors.New("logger: line does not match the log format")
+
+// ParseLine parses a line written by TextFormatter back into an Entry.
+// Trailing " trace=", " span=", " id=" and " ver=" tokens are moved from
+// the message into TraceID, SpanID, ID and Version.
+//
+// The timestamp is parsed with the configured time format in the local
+// time zone. Lines that do not follow the format, such as continuation
+// lines of multi-line messages, yield an error wrapping ErrInvalidLine.
+func ParseLine(line string) (Entry, error) {
+	var e Entry
+	line = strings.TrimRight(line, "\r\n")
+
+	ts, rest, ok := strings.Cut(line, " [")
+	if !ok {
+		return e, invalidLine("missing level")
+	}
+	t, err := time.ParseInLocation(timeFormat, ts, time.Local)
+	if err != nil {
+		return e, invalidLine("bad timestamp")
+	}
+	e.Time = t
+
+	level, rest, ok := strings.Cut(rest, "] (")
+	if !ok {
+		return e, invalidLine("missing process ID")
+	}
+	if e.Level, ok = parseLevelName(level); !ok {
+		return e, invalidLine("unknown level " + strconv.Quote(level))
+	}
+
+	pid, rest, ok := strings.Cut(rest, ")")
+	if !ok {
+		return e, invalidLine("unterminated process ID")
+	}
+	if e.PID, err = strconv.Atoi(pid); err != nil {
+		return e, invalidLine("bad process ID")
+	}
+
+	caller, rest, ok := strings.Cut(rest, " ")
+	if !ok {
+		return e, invalidLine("missing function")
+	}
+	colon := strings.LastIndexByte(caller, ':')
+	if colon < 0 {
+		return e, invalidLine("missing line number")
+	}
+	e.File = caller[:colon]
+	if e.Line, err = strconv.Atoi(caller[colon+1:]); err != nil {
+		return e, invalidLine("bad line number")
+	}
+
+	if e.Func, e.Message, ok = strings.Cut(rest, " - "); !ok {
+		return e, invalidLine("missing message separator")
+	}
+	e.Message, e.Version = cutToken(e.Message, " ver=", isVersionToken)
+	e.Message, e.ID = cutToken(e.Message, " id=", isEventID)
+	if msg, span := cutToken(e.Message, " span=", isSpanID); span != "" {
+		if msg, trace := cutToken(msg, " trace=", isTraceID); trace != "" {
+			e.Message, e.TraceID, e.SpanID = msg, trace, span
+		}
+	}
+	return e, nil
+}
+
+// cutToken splits a trailing token starting with prefix, such as the
+// " id=..." appended by TextFormatter, off msg if valid accepts its value.
+func cutToken(msg, prefix string, valid func(string) bool) (string, string) {
+	i := strings.LastIndex(msg, prefix)
+	if i < 0 || !valid(msg[i+len(prefix):]) {
+		return msg, ""
+	}
+	return msg[:i], msg[i+len(prefix):]
+}
+
+// isVersionToken reports whether s can be a build written by TextFormatter.
+func isVersionToken(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t")
+}
+
+// isEventID reports whether s is an event ID as assigned by WithEventIDs.
+func isEventID(s string) bool {
+	if len(s) != 26 || s[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(crockford, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isTraceID and isSpanID report whether s is an ID attached with
+// ContextWithTrace.
+func isTraceID(s string) bool { return isTraceHex(s, 32) }
+func isSpanID(s string) bool  { return isTraceHex(s, 16) }
+
+// invalidLine returns an error wrapping ErrInvalidLine with reason.
+func invalidLine(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidLine, reason)
+}
+
+// parseLevelName is the inverse of levelName.
+func parseLevelName(s string) (LogLevel, bool) {
+	switch s {
+	case "DEBUG":
+		return DEBUG, true
+	case "INFO":
+		return INFO, true
+	case "WARN":
+		return WARN, true
+	case "ERR":
+		return ERROR, true
+	}
+	return 0, false
+}
+
+// Scanner reads a log file in the default text format line by line.
+//
+// Lines that cannot be parsed do not stop the scan; they are reported by
+// LineErr so callers can skip or collect them:
+//
+//	s := logger.NewScanner(f)
+//	for s.Scan() {
+//		if s.LineErr() != nil {
+//			continue
+//		}
+//		e := s.Entry()
+//		...
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+type Scanner struct {
+	s       *bufio.Scanner
+	entry   Entry
+	lineErr error
+	lineNo  int
+}
+
+// NewScanner returns a Scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxLineSize)
+	return &Scanner{s: s}
+}
+
+// Scan advances to the next line, returning false at the end of the input
+// or on a read error.
+func (s *Scanner) Scan() bool {
+	if !s.s.Scan() {
+		return false
+	}
+	s.lineNo++
+	s.entry, s.lineErr = ParseLine(s.s.Text())
+	return true
+}
+
+// Entry returns the entry parsed from the current line. It is only valid
+// when LineErr returns nil.
+func (s *Scanner) Entry() Entry {
+	return s.entry
+}
+
+// LineErr returns the parse error of the current line, if any.
+func (s *Scanner) LineErr() error {
+	return s.lineErr
+}
+
+// Text returns the current line as read.
+func (s *Scanner) Text() string {
+	return s.s.Text()
+}
+
+// LineNumber returns the 1-based number of the current line.
+func (s *Scanner) LineNumber() int {
+	return s.lineNo
+}
+
+// Err returns the first read error encountered by Scan.
+func (s *Scanner) Err() error {
+	return s.s.Err()
+}
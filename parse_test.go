@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLineRoundTrip(t *testing.T) {
+	base := Entry{
+		Time:    time.Date(2026, 5, 3, 14, 0, 0, 0, time.Local),
+		Level:   WARN,
+		PID:     1234,
+		File:    "main.go",
+		Line:    12,
+		Func:    "main.run",
+		Message: "disk almost full",
+	}
+	withID := base
+	withID.ID = "01J0Z3N6Q8R5T7V9W1X3Y5Z7AB"
+	withVersion := withID
+	withVersion.Version = "v1.4.2"
+	withTrace := withVersion
+	withTrace.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	withTrace.SpanID = "00f067aa0ba902b7"
+
+	for _, tc := range []struct {
+		name string
+		e    Entry
+		f    TextFormatter
+	}{
+		{"plain", base, TextFormatter{}},
+		{"id", withID, TextFormatter{}},
+		{"id and version", withVersion, TextFormatter{Version: true}},
+		{"trace", withTrace, TextFormatter{Version: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			line := tc.f.Format(&tc.e)
+			got, err := ParseLine(line)
+			if err != nil {
+				t.Fatalf("ParseLine(%q): %v", line, err)
+			}
+			if got != tc.e {
+				t.Errorf("ParseLine(%q) = %+v, want %+v", line, got, tc.e)
+			}
+		})
+	}
+}
+
+func TestParseLineKeepsLookalikeTokens(t *testing.T) {
+	for _, msg := range []string{"lookup id=42", "set ver= to empty", "user id=01J0Z3N6Q8R5T7V9W1X3Y5Z7A"} {
+		e, err := ParseLine("2026-05-03 14:00:00 [INFO] (1)a.go:1 f - " + msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Message != msg || e.ID != "" || e.Version != "" {
+			t.Errorf("ParseLine split %q into %+v", msg, e)
+		}
+	}
+}
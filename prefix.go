@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// prefixSettings holds a global value and overrides for package path
+// prefixes, such as the level thresholds and verbosities. Readers load an
+// immutable prefixTable with a single atomic load; every change publishes
+// a new table, which also discards the per-PC cache.
+type prefixSettings[T any] struct {
+	mu    sync.Mutex
+	table atomic.Pointer[prefixTable[T]]
+}
+
+// prefixTable is an immutable snapshot of prefixSettings.
+type prefixTable[T any] struct {
+	global  T
+	modules []prefixValue[T]
+	byPC    sync.Map
+}
+
+// prefixValue is an override for a package path prefix.
+type prefixValue[T any] struct {
+	prefix string
+	value  T
+}
+
+// newPrefixSettings returns settings with the global value global and no
+// overrides.
+func newPrefixSettings[T any](global T) *prefixSettings[T] {
+	s := &prefixSettings[T]{}
+	s.table.Store(&prefixTable[T]{global: global})
+	return s
+}
+
+// load returns the current snapshot.
+func (s *prefixSettings[T]) load() *prefixTable[T] {
+	return s.table.Load()
+}
+
+// setGlobal replaces the global value, keeping the overrides.
+func (s *prefixSettings[T]) setGlobal(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.table.Load()
+	s.table.Store(&prefixTable[T]{global: v, modules: cur.modules})
+}
+
+// set overrides the value for prefix, replacing an earlier override of the
+// same prefix. The overrides are kept longest prefix first.
+func (s *prefixSettings[T]) set(prefix string, v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	cur := s.table.Load()
+	modules := make([]prefixValue[T], 0, len(cur.modules)+1)
+	for _, m := range cur.modules {
+		if m.prefix != prefix {
+			modules = append(modules, m)
+		}
+	}
+	modules = append(modules, prefixValue[T]{prefix: prefix, value: v})
+	sort.Slice(modules, func(i, j int) bool {
+		return len(modules[i].prefix) > len(modules[j].prefix)
+	})
+	s.table.Store(&prefixTable[T]{global: cur.global, modules: modules})
+}
+
+// clear removes the override for prefix.
+func (s *prefixSettings[T]) clear(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	cur := s.table.Load()
+	modules := make([]prefixValue[T], 0, len(cur.modules))
+	for _, m := range cur.modules {
+		if m.prefix != prefix {
+			modules = append(modules, m)
+		}
+	}
+	s.table.Store(&prefixTable[T]{global: cur.global, modules: modules})
+}
+
+// lookup returns the value applying to the code at pc: the override with
+// the longest prefix matching its package, or the global value. Results
+// are cached per PC for the lifetime of the table.
+func (t *prefixTable[T]) lookup(pc uintptr) T {
+	if v, ok := t.byPC.Load(pc); ok {
+		return v.(T)
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg := packagePath(frame.Function)
+	v := t.global
+	for _, m := range t.modules {
+		if pkg == m.prefix || strings.HasPrefix(pkg, m.prefix+"/") {
+			v = m.value
+			break
+		}
+	}
+	t.byPC.Store(pc, v)
+	return v
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp inserted into the names
+// of rotated log files, as in "app-2006-01-02T15-04-05.000.log". The time
+// is when the file was rotated out, so it bounds the entries it contains.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// QueryOptions selects the entries returned by Query. Zero values disable
+// the corresponding filter.
+type QueryOptions struct {
+	// MinLevel is the lowest level returned. The zero value is INFO; set
+	// it to DEBUG to include DEBUG entries.
+	MinLevel LogLevel
+
+	// From and To bound the entry timestamps, inclusively.
+	From time.Time
+	To   time.Time
+
+	// Contains, if set, must be a substring of the message.
+	Contains string
+
+	// Pattern, if set, must match the message.
+	Pattern *regexp.Regexp
+
+	// Limit caps the number of entries returned.
+	Limit int
+
+	// Keys, if set, decrypts backups encrypted with EncryptRotated.
+	// Without it, encrypted backups are skipped.
+	Keys KeyProvider
+}
+
+// Query returns the entries of the log files in dir matching opts, in
+// chronological order.
+//
+// Both the active files and their rotated backups are read, including
+// gzip-compressed ones ending in ".gz" and, given QueryOptions.Keys,
+// encrypted ones ending in ".enc". Backups whose rotation timestamp
+// shows they lie entirely outside the From/To range are not opened.
+// Lines that do not parse as entries are ignored.
+func Query(dir string, opts QueryOptions) ([]Entry, error) {
+	files, err := queryFiles(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, path := range files {
+		if entries, err = queryFile(path, opts, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+	return entries, nil
+}
+
+// queryFiles lists the log files in dir that may contain entries in the
+// requested time range, oldest first.
+func queryFiles(dir string, opts QueryOptions) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		path    string
+		rotated time.Time
+	}
+	var backups, active []candidate
+	seen := make(map[string]bool)
+	for _, de := range dirEntries {
+		if !de.Type().IsRegular() || !isLogFileName(de.Name()) {
+			continue
+		}
+		name := de.Name()
+		c := candidate{path: filepath.Join(dir, name)}
+		if strings.HasSuffix(trimArchiveExt(name), ".log") {
+			if t, ok := backupTime(name, ".log"); ok {
+				c.rotated = t
+				backups = append(backups, c)
+			} else {
+				active = append(active, c)
+			}
+			continue
+		}
+
+		// A backup of a log file without an extension; the active file is
+		// its name without the timestamp.
+		c.rotated, _ = backupTime(name, "")
+		backups = append(backups, c)
+		stem := trimArchiveExt(name)
+		base := filepath.Join(dir, stem[:len(stem)-len(backupTimeFormat)-1])
+		if !seen[base] {
+			seen[base] = true
+			if info, err := os.Stat(base); err == nil && info.Mode().IsRegular() {
+				active = append(active, candidate{path: base})
+			}
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.Before(backups[j].rotated)
+	})
+
+	var files []string
+	var prev time.Time
+	for _, c := range backups {
+		// The backup holds entries written between the previous rotation
+		// and its own.
+		inRange := (opts.From.IsZero() || !c.rotated.Before(opts.From)) &&
+			(opts.To.IsZero() || prev.IsZero() || !prev.After(opts.To))
+		if inRange {
+			files = append(files, c.path)
+		}
+		prev = c.rotated
+	}
+	if opts.To.IsZero() || prev.IsZero() || !prev.After(opts.To) {
+		for _, c := range active {
+			files = append(files, c.path)
+		}
+	}
+	return files, nil
+}
+
+// queryFile appends the matching entries of the file at path to entries.
+func queryFile(path string, opts QueryOptions, entries []Entry) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return entries, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, encSuffix) {
+		if opts.Keys == nil {
+			return entries, nil
+		}
+		if r, err = NewDecryptReader(f, opts.Keys); err != nil {
+			return entries, err
+		}
+		path = strings.TrimSuffix(path, encSuffix)
+	}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return entries, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	s := NewScanner(r)
+	for s.Scan() {
+		if s.LineErr() != nil {
+			continue
+		}
+		if e := s.Entry(); opts.match(&e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, s.Err()
+}
+
+// match reports whether e passes every filter of opts.
+func (opts *QueryOptions) match(e *Entry) bool {
+	if e.Level < opts.MinLevel {
+		return false
+	}
+	if !opts.From.IsZero() && e.Time.Before(opts.From) {
+		return false
+	}
+	if !opts.To.IsZero() && e.Time.After(opts.To) {
+		return false
+	}
+	if opts.Contains != "" && !strings.Contains(e.Message, opts.Contains) {
+		return false
+	}
+	if opts.Pattern != nil && !opts.Pattern.MatchString(e.Message) {
+		return false
+	}
+	return true
+}
+
+// isLogFileName reports whether name looks like a log file or a compressed
+// or encrypted backup of one: a ".log" file, or the backup of a log file
+// without an extension.
+func isLogFileName(name string) bool {
+	if strings.HasSuffix(trimArchiveExt(name), ".log") {
+		return true
+	}
+	_, ok := backupTime(name, "")
+	return ok
+}
+
+// trimArchiveExt removes the extensions added to a backup by encryption
+// and compression from name.
+func trimArchiveExt(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, encSuffix), ".gz")
+}
+
+// backupTime extracts the rotation timestamp from the name of a rotated
+// log file whose log path has the extension ext, possibly empty. The
+// extension cannot be derived from the backup name, since the timestamp
+// itself contains a dot.
+func backupTime(name, ext string) (time.Time, bool) {
+	name = trimArchiveExt(name)
+	if !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+	name = strings.TrimSuffix(name, ext)
+	if len(name) <= len(backupTimeFormat) || name[len(name)-len(backupTimeFormat)-1] != '-' {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, name[len(name)-len(backupTimeFormat):], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
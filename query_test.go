@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureEntry is an entry of a query fixture, logged at hh:mm on
+// 2026-01-01.
+type fixtureEntry struct {
+	at    string
+	level LogLevel
+	msg   string
+}
+
+// writeFixture writes entries in the text format to dir/name, gzipped if
+// the name ends in ".gz".
+func writeFixture(t *testing.T, dir, name string, entries ...fixtureEntry) {
+	t.Helper()
+	var b strings.Builder
+	for _, fe := range entries {
+		e := Entry{Time: fixtureTime(t, fe.at), Level: fe.level, PID: 7, File: "app.go", Line: 1, Func: "run", Message: fe.msg}
+		b.WriteString(TextFormatter{}.Format(&e) + "\n")
+	}
+
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !strings.HasSuffix(name, ".gz") {
+		if _, err := f.WriteString(b.String()); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(b.String())); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fixtureTime(t *testing.T, hhmm string) time.Time {
+	t.Helper()
+	at, err := time.ParseInLocation("2006-01-02 15:04", "2026-01-01 "+hhmm, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func TestQueryMixedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "app-2026-01-01T10-00-00.000.log.gz",
+		fixtureEntry{"09:00", INFO, "started"},
+		fixtureEntry{"09:30", DEBUG, "cache warm"},
+		fixtureEntry{"09:59", WARN, "slow disk"},
+	)
+	writeFixture(t, dir, "app-2026-01-01T11-00-00.000.log.gz",
+		fixtureEntry{"10:15", INFO, "request served"},
+		// Entries of a file can be slightly out of order.
+		fixtureEntry{"10:10", INFO, "late flush"},
+		fixtureEntry{"10:45", ERROR, "request failed"},
+	)
+	writeFixture(t, dir, "app.log",
+		fixtureEntry{"11:05", INFO, "request served"},
+		fixtureEntry{"11:30", ERROR, "shutdown"},
+	)
+	writeFixture(t, dir, "notes.txt", fixtureEntry{"10:20", ERROR, "not a log"})
+
+	tests := []struct {
+		name string
+		opts QueryOptions
+		want []string
+	}{
+		{
+			name: "everything in order",
+			opts: QueryOptions{MinLevel: DEBUG},
+			want: []string{"09:00 started", "09:30 cache warm", "09:59 slow disk", "10:10 late flush", "10:15 request served", "10:45 request failed", "11:05 request served", "11:30 shutdown"},
+		},
+		{
+			name: "default level skips DEBUG",
+			opts: QueryOptions{From: fixtureTime(t, "09:00"), To: fixtureTime(t, "10:00")},
+			want: []string{"09:00 started", "09:59 slow disk"},
+		},
+		{
+			name: "range spanning a backup and the active file",
+			opts: QueryOptions{From: fixtureTime(t, "10:12"), To: fixtureTime(t, "11:05")},
+			want: []string{"10:15 request served", "10:45 request failed", "11:05 request served"},
+		},
+		{
+			name: "range within the active file",
+			opts: QueryOptions{From: fixtureTime(t, "11:10")},
+			want: []string{"11:30 shutdown"},
+		},
+		{
+			name: "range within the first backup",
+			opts: QueryOptions{To: fixtureTime(t, "09:45"), MinLevel: DEBUG},
+			want: []string{"09:00 started", "09:30 cache warm"},
+		},
+		{
+			name: "level and pattern",
+			opts: QueryOptions{MinLevel: WARN, Pattern: regexp.MustCompile(`^(slow|request)`)},
+			want: []string{"09:59 slow disk", "10:45 request failed"},
+		},
+		{
+			name: "contains with limit",
+			opts: QueryOptions{Contains: "request", Limit: 2},
+			want: []string{"10:15 request served", "10:45 request failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Query(dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Time.Format("15:04")+" "+e.Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuerySkipsBackupsOutsideRange(t *testing.T) {
+	dir := t.TempDir()
+	// Not valid gzip: opening it would fail the query.
+	if err := os.WriteFile(filepath.Join(dir, "app-2026-01-01T10-00-00.000.log.gz"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFixture(t, dir, "app-2026-01-01T11-00-00.000.log.gz", fixtureEntry{"10:30", INFO, "kept"})
+	writeFixture(t, dir, "app.log", fixtureEntry{"11:30", INFO, "current"})
+
+	entries, err := Query(dir, QueryOptions{From: fixtureTime(t, "10:20"), To: fixtureTime(t, "10:40")})
+	if err != nil {
+		t.Fatalf("Query opened a backup outside the range: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Message != "kept" {
+		t.Errorf("Query = %+v, want the entry at 10:30", entries)
+	}
+}
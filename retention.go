@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithMaxTotalSize limits the combined size of the active log file and its
+// rotated files, compressed or not, to n bytes. With WithDateLayout, the
+// files in the directories of earlier dates count as well.
+//
+// The limit is enforced when the logger is initialized and after every
+// rotation by deleting the oldest rotated files until the total fits. The
+// active file is never removed, so it alone may exceed the limit. A limit
+// of zero, the default, disables the check.
+func WithMaxTotalSize(n int64) Option {
+	return func(c *config) {
+		c.maxTotalSize = n
+	}
+}
+
+// enforceTotalSize deletes the oldest backups of the active log file until
+// the total size is within the configured limit. The caller must hold mu.
+func enforceTotalSize() {
+	if cfg.maxTotalSize <= 0 || logFile == nil {
+		return
+	}
+
+	backups, err := retainedFiles()
+	if err != nil {
+		return
+	}
+
+	var total int64
+	if info, err := logFile.Stat(); err == nil {
+		total = info.Size()
+	}
+	for _, b := range backups {
+		total += b.size
+	}
+
+	removed := 0
+	for _, b := range backups {
+		if total <= cfg.maxTotalSize {
+			break
+		}
+		if err := os.Remove(b.path); err != nil {
+			logInternal(WARN, fmt.Sprintf("failed to remove old log file: %v", err))
+			continue
+		}
+		total -= b.size
+		removed++
+	}
+	if removed > 0 {
+		logInternal(INFO, fmt.Sprintf("removed %d rotated log files to stay under %d bytes", removed, cfg.maxTotalSize))
+	}
+}
+
+// retainedFiles returns the files of the log that retention may delete,
+// oldest first: the rotated files of the active log and, with a date
+// layout, the log files left in the directories of earlier dates together
+// with their rotated files. The caller must hold mu.
+func retainedFiles() ([]backupFile, error) {
+	if cfg.dateLayout == "" || nameTemplate == nil {
+		return listBackups(logPath)
+	}
+
+	root := filepath.Dir(nameTemplate.expand(clockNow()))
+	base := filepath.Base(logPath)
+	depth := strings.Count(cfg.dateLayout, "/") + 1
+
+	var files []backupFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if n := strings.Count(filepath.ToSlash(rel), "/") + 1; n < depth {
+			return nil
+		} else if n > depth {
+			return filepath.SkipDir
+		}
+		if _, err := time.Parse(cfg.dateLayout, filepath.ToSlash(rel)); err != nil {
+			return filepath.SkipDir
+		}
+
+		name := filepath.Join(path, base)
+		backups, err := listBackups(name)
+		if err != nil {
+			return filepath.SkipDir
+		}
+		files = append(files, backups...)
+		if name != logPath {
+			if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+				files = append(files, backupFile{path: name, rotated: info.ModTime(), size: info.Size()})
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].rotated.Before(files[j].rotated)
+	})
+	return files, nil
+}
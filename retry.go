@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy retries a failed delivery with exponential backoff and full
+// jitter: before attempt n+1 it waits a random duration between zero and
+// InitialDelay * Multiplier^(n-1), capped at MaxDelay.
+//
+// Retrying stops after MaxAttempts attempts or once MaxElapsed has passed
+// since the first one, whichever comes first; zero disables a limit, but
+// at least one should be set. Errors for which Retryable returns false,
+// and errors wrapped with Permanent, are returned immediately.
+type RetryPolicy struct {
+	InitialDelay time.Duration
+	Multiplier   float64
+	MaxDelay     time.Duration
+	MaxAttempts  int
+	MaxElapsed   time.Duration
+
+	// Retryable classifies errors. If nil, every error not wrapped with
+	// Permanent is retried.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy starts at 100ms, doubles up to 30s and gives up after
+// five attempts.
+var DefaultRetryPolicy = RetryPolicy{
+	InitialDelay: 100 * time.Millisecond,
+	Multiplier:   2,
+	MaxDelay:     30 * time.Second,
+	MaxAttempts:  5,
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that RetryPolicy.Do returns it without retrying,
+// for failures such as a rejected request that would fail again.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+var (
+	// retryJitter is a variable so that tests can make the delays between
+	// attempts deterministic.
+	retryJitter = fullJitter
+
+	retries atomic.Uint64
+)
+
+// fullJitter returns a random duration in [0, max].
+func fullJitter(max time.Duration) time.Duration {
+	return rand.N(max + 1)
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable
+// or the policy gives up, and returns the last error. Waiting between
+// attempts ends early when ctx is done, in which case the context's error
+// is joined with the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	start := clockNow()
+	delay := float64(p.InitialDelay)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !p.retryable(err) {
+			return err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return err
+		}
+
+		d := time.Duration(delay)
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+		wait := time.Duration(0)
+		if d > 0 {
+			wait = retryJitter(d)
+		}
+		if p.MaxElapsed > 0 && clockNow().Add(wait).Sub(start) > p.MaxElapsed {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return errors.Join(err, ctx.Err())
+		}
+		t := newClockTimer(wait)
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Join(err, ctx.Err())
+		}
+		retries.Add(1)
+
+		if p.Multiplier > 1 {
+			delay = math.Min(delay*p.Multiplier, math.MaxInt64/2)
+		}
+	}
+}
+
+// retryable reports whether err may be retried under p.
+func (p RetryPolicy) retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
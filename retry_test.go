@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// useSeededJitter draws the retry jitter from a source seeded with seed for
+// the duration of the test. Every wait chosen is sent to the returned
+// channel.
+func useSeededJitter(t *testing.T, seed uint64) <-chan time.Duration {
+	r := rand.New(rand.NewPCG(seed, seed))
+	waits := make(chan time.Duration, 100)
+	retryJitter = func(max time.Duration) time.Duration {
+		d := time.Duration(r.Int64N(int64(max) + 1))
+		waits <- d
+		return d
+	}
+	t.Cleanup(func() { retryJitter = fullJitter })
+	return waits
+}
+
+// runRetry runs p.Do with fn in a goroutine, advancing clock by each wait
+// chosen by the jitter, and returns the error of Do with the waits.
+func runRetry(t *testing.T, clock *fakeClock, waits <-chan time.Duration, p RetryPolicy, fn func() error) (error, []time.Duration) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- p.Do(context.Background(), fn) }()
+
+	var got []time.Duration
+	for {
+		select {
+		case err := <-done:
+			return err, got
+		case d := <-waits:
+			got = append(got, d)
+			for clock.Waiters() == 0 {
+				select {
+				case err := <-done:
+					// The policy gave up instead of waiting.
+					return err, got
+				case <-time.After(time.Millisecond):
+				}
+			}
+			clock.Advance(d)
+		case <-time.After(5 * time.Second):
+			t.Fatal("retry did not finish")
+		}
+	}
+}
+
+func TestRetryDelaySequence(t *testing.T) {
+	const seed = 42
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	clock := useFakeClock(t, start)
+	waits := useSeededJitter(t, seed)
+
+	p := RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		Multiplier:   2,
+		MaxDelay:     time.Second,
+		MaxAttempts:  7,
+	}
+	failed := errors.New("unavailable")
+	attempts := 0
+	err, got := runRetry(t, clock, waits, p, func() error {
+		attempts++
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Do = %v, want %v", err, failed)
+	}
+	if attempts != 7 {
+		t.Errorf("%d attempts, want 7", attempts)
+	}
+
+	// The same seed must choose the same waits below the growing caps.
+	r := rand.New(rand.NewPCG(seed, seed))
+	var want []time.Duration
+	var total time.Duration
+	for _, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		d := time.Duration(r.Int64N(int64(max*time.Millisecond) + 1))
+		want = append(want, d)
+		total += d
+	}
+	if len(got) != len(want) {
+		t.Fatalf("waited %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("wait %d = %v, want %v", i+1, got[i], want[i])
+		}
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != total {
+		t.Errorf("retrying took %v, want the sum of the waits %v", elapsed, total)
+	}
+}
+
+func TestRetryMaxElapsed(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local))
+	waits := useSeededJitter(t, 7)
+
+	p := RetryPolicy{InitialDelay: time.Second, Multiplier: 2, MaxElapsed: 10 * time.Second}
+	attempts := 0
+	_, got := runRetry(t, clock, waits, p, func() error {
+		attempts++
+		return errors.New("unavailable")
+	})
+	var total time.Duration
+	for _, d := range got[:len(got)-1] {
+		total += d
+	}
+	if total > p.MaxElapsed || total+got[len(got)-1] <= p.MaxElapsed {
+		t.Errorf("waits %v: gave up at the wrong point for MaxElapsed %v", got, p.MaxElapsed)
+	}
+	if attempts != len(got) {
+		t.Errorf("%d attempts for %d waits, want one retry per wait taken and the first attempt", attempts, len(got))
+	}
+}
+
+func TestRetryPermanent(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local))
+	rejected := errors.New("400 bad request")
+	for _, tt := range []struct {
+		name string
+		p    RetryPolicy
+		err  error
+	}{
+		{"wrapped", DefaultRetryPolicy, Permanent(rejected)},
+		{"classified", RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool { return err != rejected }}, rejected},
+	} {
+		attempts := 0
+		err := tt.p.Do(context.Background(), func() error {
+			attempts++
+			return tt.err
+		})
+		if !errors.Is(err, rejected) || attempts != 1 {
+			t.Errorf("%s: Do = %v after %d attempts, want %v after 1", tt.name, err, attempts, rejected)
+		}
+	}
+}
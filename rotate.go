@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rotate moves the active log file aside and continues logging to a new,
+// empty file at the same path. It returns the path the old file was moved
+// to.
+//
+// The rotated file is named after the active one with the current time
+// inserted before the extension, such as "app-2024-05-03T14-00-00.000.log",
+// which Query uses to order and skip backups. Rotate is safe to call while
+// other goroutines are logging; with WithFileLock it also excludes writers
+// in other processes.
+func Rotate() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile == nil {
+		return "", errors.New("logger: no log file to rotate")
+	}
+
+	if lock != nil {
+		if err := lock.lock(true); err != nil {
+			return "", fmt.Errorf("failed to lock log file: %w", err)
+		}
+		defer lock.unlock()
+		reopenIfMoved()
+	}
+
+	return rotateLocked(clockNow())
+}
+
+// rotateLocked renames the active log file to a backup name for now and
+// opens a fresh file, expanding the filename template again so that
+// {date} and {time} reflect the time of the rotation. The caller must hold
+// mu and, with file locking, the exclusive lock.
+func rotateLocked(now time.Time) (string, error) {
+	backup := backupName(logPath, now)
+	if err := os.Rename(logPath, backup); err != nil {
+		return "", fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	path, mode := logPath, OpenTruncate
+	if nameTemplate != nil {
+		if next := resolveLogPath(nameTemplate, now); next != path {
+			path, mode = next, OpenAppend
+		}
+	}
+	f, err := openLogFile(path, mode)
+	if err != nil {
+		return backup, fmt.Errorf("failed to reopen log file after rotation: %w", err)
+	}
+	swapFile(f, path)
+	dateDir = dateKey(now)
+	enforceTotalSize()
+	notifyRotate(backup, path)
+	return backup, nil
+}
+
+// backupName returns an unused name for the backup of path rotated at now.
+func backupName(path string, now time.Time) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for {
+		name := base + "-" + now.Format(backupTimeFormat) + ext
+		if _, err := os.Lstat(name); errors.Is(err, os.ErrNotExist) {
+			return name
+		}
+		now = now.Add(time.Millisecond)
+	}
+}
+
+// backupFile is a rotated log file found on disk.
+type backupFile struct {
+	path    string
+	rotated time.Time
+	size    int64
+}
+
+// listBackups returns the rotated files of the log at path, compressed,
+// encrypted or not, oldest first.
+func listBackups(path string) ([]backupFile, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, de := range entries {
+		name := de.Name()
+		if !de.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stem := trimArchiveExt(name)
+		if !strings.HasSuffix(stem, ext) || len(stem) != len(prefix)+len(backupTimeFormat)+len(ext) {
+			continue
+		}
+		t, ok := backupTime(name, ext)
+		if !ok {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), rotated: t, size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.Before(backups[j].rotated)
+	})
+	return backups, nil
+}
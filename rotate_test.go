@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateExpandsTemplate(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 23, 59, 58, 0, time.Local))
+	dir := initTestLogger(t, "app-{date}.log")
+
+	Info("before")
+	clock.Advance(3 * time.Second)
+	backup, err := Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	Info("after")
+
+	if want := filepath.Join(dir, "app-2026-03-01-2026-03-02T00-00-01.000.log"); backup != want {
+		t.Errorf("backup = %q, want %q", backup, want)
+	}
+	lines := readLines(t, filepath.Join(dir, "app-2026-03-02.log"))
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], " - after") {
+		t.Errorf("new file holds %q", lines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-2026-03-01.log")); !os.IsNotExist(err) {
+		t.Errorf("old name still exists: %v", err)
+	}
+}
+
+// TestRotateUnderLoad rotates three times while goroutines are logging and
+// checks that every entry ended up in exactly one of the files.
+func TestRotateUnderLoad(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+
+	const goroutines, entries = 8, 1000
+	var backups []string
+	rotate := func() {
+		backup, err := Rotate()
+		if err != nil {
+			t.Errorf("Rotate: %v", err)
+		}
+		backups = append(backups, backup)
+	}
+	logConcurrently(goroutines, entries, rotate, rotate, rotate)
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 3 || backups[0] == backups[1] || backups[1] == backups[2] {
+		t.Fatalf("Rotate returned %q, want three distinct backups", backups)
+	}
+	checkEntriesOnce(t, goroutines, entries, append(backups, filepath.Join(dir, "app.log"))...)
+}
+
+func TestDateTemplateRollsOver(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 23, 59, 59, 0, time.Local))
+	dir := initTestLogger(t, "app-{date}.log")
+
+	Info("day one")
+	clock.Advance(2 * time.Second)
+	Info("day two")
+
+	for name, want := range map[string]string{
+		"app-2026-03-01.log": " - day one",
+		"app-2026-03-02.log": " - day two",
+	} {
+		lines := readLines(t, filepath.Join(dir, name))
+		if len(lines) != 1 || !strings.HasSuffix(lines[0], want) {
+			t.Errorf("%s holds %q, want one line ending in %q", name, lines, want)
+		}
+	}
+}
+
+func TestTotalSizeCoversDateLayout(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 23, 59, 59, 0, time.Local))
+	dir := initTestLogger(t, "app.log", WithDateLayout("2006/01/02"), WithMaxTotalSize(1500))
+	msg := strings.Repeat("x", 1000)
+
+	Info("%s", msg)
+	clock.Advance(2 * time.Second)
+	Info("%s", msg)
+	old := filepath.Join(dir, "2026", "03", "01", "app.log")
+	if err := os.Chtimes(old, clock.Now(), time.Date(2026, 3, 1, 23, 59, 59, 0, time.Local)); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("file of the earlier date was kept: %v", err)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("newest backup was removed: %v", err)
+	}
+}
+
+func TestBackupsWithoutExtension(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app", WithMaxTotalSize(2500))
+	msg := strings.Repeat("x", 1000)
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		Info("%d %s", i, msg)
+		clock.Advance(time.Minute)
+		backup, err := Rotate()
+		if err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+		backups = append(backups, backup)
+	}
+	Info("3 current")
+
+	if want := filepath.Join(dir, "app-2026-03-01T12-01-00.000"); backups[0] != want {
+		t.Errorf("backup = %q, want %q", backups[0], want)
+	}
+	if _, err := os.Stat(backups[0]); !os.IsNotExist(err) {
+		t.Errorf("oldest backup was kept over the size limit: %v", err)
+	}
+	mu.Lock()
+	listed, err := listBackups(logPath)
+	mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || listed[0].path != backups[1] || listed[1].path != backups[2] {
+		t.Errorf("listBackups = %+v, want %q", listed, backups[1:])
+	}
+
+	entries, err := Query(dir, QueryOptions{Pattern: regexp.MustCompile(`^\d `)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Message[:1])
+	}
+	if strings.Join(got, "") != "123" {
+		t.Errorf("Query returned entries %q, want 1, 2 and 3", got)
+	}
+}
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoggingRoundTripper is an http.RoundTripper logging every outbound
+// request. It is created with NewLoggingRoundTripper.
+type LoggingRoundTripper struct {
+	next        http.RoundTripper
+	redactQuery bool
+	headers     []string
+	bodyLimit   int
+}
+
+// RoundTripperOption configures a LoggingRoundTripper.
+type RoundTripperOption func(rt *LoggingRoundTripper)
+
+// WithRedactedQuery replaces the query string of logged URLs with
+// "REDACTED", for APIs passing credentials as query parameters.
+func WithRedactedQuery() RoundTripperOption {
+	return func(rt *LoggingRoundTripper) {
+		rt.redactQuery = true
+	}
+}
+
+// WithLoggedHeaders logs the values of the named request and response
+// headers. No headers are logged by default, so credentials such as
+// Authorization are never written unless explicitly listed.
+func WithLoggedHeaders(names ...string) RoundTripperOption {
+	return func(rt *LoggingRoundTripper) {
+		for _, name := range names {
+			rt.headers = append(rt.headers, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// WithBodyCapture logs up to limit bytes of the request and response
+// bodies in a separate entry at DEBUG. Capturing reads the start of the
+// response body before RoundTrip returns; the caller still receives the
+// complete body.
+func WithBodyCapture(limit int) RoundTripperOption {
+	return func(rt *LoggingRoundTripper) {
+		rt.bodyLimit = limit
+	}
+}
+
+// NewLoggingRoundTripper returns a RoundTripper that sends requests through
+// next, or http.DefaultTransport if next is nil, and logs the method, URL,
+// status and duration of each.
+//
+// Successful requests are logged at INFO, responses with a 5xx status at
+// WARN and transport errors at ERROR. Requests aborted because their
+// context was canceled or timed out are logged at WARN as such.
+func NewLoggingRoundTripper(next http.RoundTripper, opts ...RoundTripperOption) *LoggingRoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	rt := &LoggingRoundTripper{next: next}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	return rt
+}
+
+// RoundTrip implements http.RoundTripper.
+//
+// Entries are attributed to the code that sent the request, the first
+// caller outside of net/http, and module levels are matched against it.
+// A failure to capture the response body is logged and does not fail the
+// request.
+func (rt *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cs := roundTripCaller()
+	var reqBody []byte
+	if rt.bodyLimit > 0 && req.Body != nil && req.Body != http.NoBody && enabledAt(DEBUG, cs.pc) {
+		// RoundTrippers must not modify the request, so the body is
+		// replaced on a copy.
+		req = req.Clone(req.Context())
+		var err error
+		if reqBody, req.Body, err = peekBody(req.Body, rt.bodyLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	start := clockNow()
+	resp, err := rt.next.RoundTrip(req)
+	elapsed := clockSince(start).Round(time.Microsecond)
+
+	target := rt.logURL(req.URL)
+	var b strings.Builder
+	level := INFO
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		level = WARN
+		fmt.Fprintf(&b, "HTTP %s %s canceled after %v", req.Method, target, elapsed)
+	case err != nil && errors.Is(err, context.DeadlineExceeded):
+		level = WARN
+		fmt.Fprintf(&b, "HTTP %s %s timed out after %v", req.Method, target, elapsed)
+	case err != nil:
+		level = ERROR
+		fmt.Fprintf(&b, "HTTP %s %s failed after %v: %v", req.Method, target, elapsed, err)
+	default:
+		if resp.StatusCode >= 500 {
+			level = WARN
+		}
+		fmt.Fprintf(&b, "HTTP %s %s -> %d (%v)", req.Method, target, resp.StatusCode, elapsed)
+	}
+	rt.appendHeaders(&b, "req", req.Header)
+	if resp != nil {
+		rt.appendHeaders(&b, "resp", resp.Header)
+	}
+	if enabledAt(level, cs.pc) {
+		write(entryAt(clockNow(), level, cs, b.String()))
+	}
+
+	if rt.bodyLimit > 0 && enabledAt(DEBUG, cs.pc) {
+		var respBody []byte
+		if resp != nil && resp.Body != nil {
+			var perr error
+			if respBody, resp.Body, perr = peekBody(resp.Body, rt.bodyLimit); perr != nil {
+				write(entryAt(clockNow(), WARN, cs, fmt.Sprintf("HTTP %s %s response body capture failed: %v", req.Method, target, perr)))
+			}
+		}
+		write(entryAt(clockNow(), DEBUG, cs, fmt.Sprintf("HTTP %s %s request body %q response body %q", req.Method, target, reqBody, respBody)))
+	}
+	return resp, err
+}
+
+// roundTripCaller returns the call site of the code sending a request
+// through RoundTrip: the first frame above RoundTrip outside of net/http
+// and the registered wrapper packages. If there is none
+// within maxCallerDepth frames, it is the caller of RoundTrip.
+func roundTripCaller() callSite {
+	var prefixes []string
+	if p := skipPrefixes.Load(); p != nil {
+		prefixes = *p
+	}
+
+	var pcs [maxCallerDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		pkg := packagePath(f.Function)
+		if f.Function != "" && pkg != "net/http" && (pkg == selfPackage || !isWrapperPackage(pkg, prefixes)) {
+			return shortCallSite(f.PC, f.File, f.Line, f.Function)
+		}
+		if !more {
+			return captureCallSite(2)
+		}
+	}
+}
+
+// logURL returns u as it should appear in the log.
+func (rt *LoggingRoundTripper) logURL(u *url.URL) string {
+	if !rt.redactQuery || u.RawQuery == "" {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = "REDACTED"
+	return redacted.String()
+}
+
+// appendHeaders appends the allow-listed headers of h to b.
+func (rt *LoggingRoundTripper) appendHeaders(b *strings.Builder, prefix string, h http.Header) {
+	for _, name := range rt.headers {
+		if values := h.Values(name); len(values) > 0 {
+			b.WriteString(" " + prefix + "." + name + "=" + strconv.Quote(strings.Join(values, ", ")))
+		}
+	}
+}
+
+// peekBody reads up to limit bytes from body and returns them together
+// with a ReadCloser yielding the complete, unmodified body. If reading
+// fails, the ReadCloser yields the bytes read before the error and then
+// continues with body.
+func peekBody(body io.ReadCloser, limit int) ([]byte, io.ReadCloser, error) {
+	buf := make([]byte, limit)
+	n, err := io.ReadFull(body, buf)
+	buf = buf[:n]
+	rc := readCloser{io.MultiReader(bytes.NewReader(buf), body), body}
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, rc, err
+	}
+	return buf, rc, nil
+}
+
+// readCloser combines a Reader with the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTripperLogsOutcomes(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 4, 1, 10, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	client := &http.Client{Transport: NewLoggingRoundTripper(nil, WithRedactedQuery())}
+	get := func(ctx context.Context, url string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(context.Background(), srv.URL+"/ok?token=secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(context.Background(), srv.URL+"/fail"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := get(ctx, srv.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("slow request returned %v, want a deadline error", err)
+	}
+	if err := get(context.Background(), down.URL+"/"); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	want := []struct {
+		level LogLevel
+		msg   string
+	}{
+		{INFO, "HTTP GET " + srv.URL + "/ok?REDACTED -> 200 (0s)"},
+		{WARN, "HTTP GET " + srv.URL + "/fail -> 502 (0s)"},
+		{WARN, "HTTP GET " + srv.URL + "/slow timed out after 0s"},
+		{ERROR, "HTTP GET " + down.URL + "/ failed after 0s: "},
+	}
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	if len(es) != len(want) {
+		t.Fatalf("logged %d entries, want %d: %+v", len(es), len(want), es)
+	}
+	for i, e := range es {
+		if e.Level != want[i].level || !strings.HasPrefix(e.Message, want[i].msg) {
+			t.Errorf("entry %d = %v %q, want %v %q", i, e.Level, e.Message, want[i].level, want[i].msg)
+		}
+		if e.File != "roundtrip_test.go" {
+			t.Errorf("entry %d attributed to %s:%d, want the caller of client.Do", i, e.File, e.Line)
+		}
+	}
+}
+
+// failingBody returns data and then fails.
+type failingBody struct {
+	data string
+}
+
+func (b *failingBody) Read(p []byte) (int, error) {
+	if b.data == "" {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func (b *failingBody) Close() error { return nil }
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRoundTripperBodyCapture(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 4, 1, 10, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("echo " + string(body)))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewLoggingRoundTripper(nil, WithBodyCapture(6))}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "echo hello world" {
+		t.Errorf("response body %q, want the complete body", body)
+	}
+
+	broken := NewLoggingRoundTripper(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: &failingBody{data: "par"}, Request: req}, nil
+	}), WithBodyCapture(6))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp, err = broken.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip failed on a body capture error: %v", err)
+	}
+	if data, err := io.ReadAll(resp.Body); string(data) != "par" || err == nil {
+		t.Errorf("body read %q, %v; want the bytes before the error and the error", data, err)
+	}
+
+	var got []string
+	for _, e := range readEntries(t, filepath.Join(dir, "app.log")) {
+		got = append(got, e.Message)
+	}
+	want := []string{
+		"HTTP POST " + srv.URL + " -> 200 (0s)",
+		`HTTP POST ` + srv.URL + ` request body "hello " response body "echo h"`,
+		"HTTP GET http://example.com/ -> 200 (0s)",
+		"HTTP GET http://example.com/ response body capture failed: connection reset",
+		`HTTP GET http://example.com/ request body "" response body ""`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
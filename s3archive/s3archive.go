@@ -0,0 +1,351 @@
+// Package s3archive uploads rotated log files to S3-compatible object
+// storage.
+//
+// It is kept out of the logger package so that applications that do not
+// archive logs do not pull in the networking code. Requests are signed
+// with AWS Signature Version 4 and use path-style URLs, which works with
+// AWS S3 as well as MinIO, Ceph and similar services.
+//
+//	a, err := s3archive.New(s3archive.Config{
+//		Endpoint:  "https://s3.eu-west-1.amazonaws.com",
+//		Region:    "eu-west-1",
+//		Bucket:    "acme-logs",
+//		KeyPrefix: "app/{host}/{date}",
+//		...
+//	})
+//	logger.OnRotate(a.Hook())
+//	a.ResumePending(context.Background(), "logs/app-*.log*")
+package s3archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/73ddy-io/logger"
+)
+
+// uploadedSuffix marks files that have been uploaded but are kept locally.
+const uploadedSuffix = ".uploaded"
+
+// tmpSuffix ends the names of files still being written by a rotate hook,
+// such as logger.EncryptRotated, which renames them once complete.
+const tmpSuffix = ".tmp"
+
+// Config describes the bucket rotated files are uploaded to.
+type Config struct {
+	// Endpoint is the base URL of the service, such as
+	// "https://s3.us-east-1.amazonaws.com" or "http://minio:9000".
+	Endpoint string
+
+	// Region is the signing region, such as "us-east-1".
+	Region string
+
+	// Bucket is the destination bucket.
+	Bucket string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials.
+	// SessionToken is only needed for temporary credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// KeyPrefix is prepended to the file name to form the object key. It
+	// may contain {date} (the upload date, as 2006-01-02) and {host}.
+	KeyPrefix string
+
+	// DeleteAfterUpload removes local files once they are uploaded and
+	// verified. Otherwise an empty ".uploaded" marker file is created
+	// next to each uploaded file.
+	DeleteAfterUpload bool
+
+	// Retry controls retrying a failed upload before it is left for a
+	// later retry; logger.DefaultRetryPolicy if nil. Only network errors
+	// and 429 and 5xx responses are retried.
+	Retry *logger.RetryPolicy
+
+	// HTTPClient is used for requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Archiver uploads files to the configured bucket.
+type Archiver struct {
+	cfg      Config
+	endpoint *url.URL
+	host     string
+
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// New validates cfg and returns an Archiver.
+func New(cfg Config) (*Archiver, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3archive: bucket and region are required")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("s3archive: invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Retry == nil {
+		cfg.Retry = &logger.DefaultRetryPolicy
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	host, _ := os.Hostname()
+	return &Archiver{cfg: cfg, endpoint: u, host: host, failed: make(map[string]bool)}, nil
+}
+
+// Hook returns a callback for logger.OnRotate uploading each rotated file.
+// If earlier hooks compressed or encrypted the file, to oldPath + ".gz",
+// ".enc" or ".gz.enc", that file is uploaded instead. Uploads that failed
+// earlier are retried after every rotation.
+func (a *Archiver) Hook() func(oldPath, newPath string) {
+	return func(oldPath, newPath string) {
+		ctx := context.Background()
+		for _, ext := range []string{".gz.enc", ".enc", ".gz"} {
+			if _, err := os.Stat(oldPath + ext); err == nil {
+				oldPath += ext
+				break
+			}
+		}
+		if err := a.Upload(ctx, oldPath); err != nil {
+			logger.Warn("failed to archive %s: %v", oldPath, err)
+		}
+		a.retryFailed(ctx)
+	}
+}
+
+// ResumePending uploads the files matching the glob pattern that have not
+// been uploaded yet, such as those left behind by a crash or an earlier
+// failure. Marker files and the temporary files of unfinished compression
+// or encryption are ignored. It is meant to run at startup.
+func (a *Archiver) ResumePending(ctx context.Context, pattern string) error {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+
+	var errs []error
+	for _, name := range matches {
+		if strings.HasSuffix(name, uploadedSuffix) || strings.HasSuffix(name, tmpSuffix) {
+			continue
+		}
+		if _, err := os.Stat(name + uploadedSuffix); err == nil {
+			continue
+		}
+		if err := a.Upload(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Upload uploads the file at name, verifying the checksum reported by the
+// service, and then deletes or marks it as configured. Failed uploads are
+// remembered and retried by the hook after the next rotation.
+func (a *Archiver) Upload(ctx context.Context, name string) error {
+	err := a.cfg.Retry.Do(ctx, func() error { return a.put(ctx, name) })
+	if err != nil {
+		a.markFailed(name, true)
+		return err
+	}
+	a.markFailed(name, false)
+
+	if a.cfg.DeleteAfterUpload {
+		return os.Remove(name)
+	}
+	// The marker gets the permissions of the uploaded file, which the
+	// logger created with its configured file mode.
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name+uploadedSuffix, nil, info.Mode().Perm())
+}
+
+// markFailed records whether name still needs to be uploaded.
+func (a *Archiver) markFailed(name string, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if failed {
+		a.failed[name] = true
+	} else {
+		delete(a.failed, name)
+	}
+}
+
+// retryFailed retries every upload that failed earlier.
+func (a *Archiver) retryFailed(ctx context.Context) {
+	a.mu.Lock()
+	names := make([]string, 0, len(a.failed))
+	for name := range a.failed {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			a.markFailed(name, false)
+			continue
+		}
+		if err := a.Upload(ctx, name); err != nil {
+			logger.Warn("failed to archive %s: %v", name, err)
+		}
+	}
+}
+
+// objectKey returns the key the file at name is uploaded to.
+func (a *Archiver) objectKey(name string) string {
+	prefix := strings.NewReplacer(
+		"{date}", logger.CurrentClock().Now().Format("2006-01-02"),
+		"{host}", a.host,
+	).Replace(a.cfg.KeyPrefix)
+	return strings.TrimPrefix(path.Join(prefix, filepath.Base(name)), "/")
+}
+
+// put uploads the file at name with a single signed PUT request. The
+// Content-MD5 header makes the service reject the upload if the data it
+// received does not match the local file. Failures that a retry would not
+// fix are wrapped with logger.Permanent.
+func (a *Archiver) put(ctx context.Context, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return logger.Permanent(err)
+	}
+	defer f.Close()
+
+	md5sum, sha, size, err := fileDigests(f)
+	if err != nil {
+		return logger.Permanent(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return logger.Permanent(err)
+	}
+
+	u := *a.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + a.cfg.Bucket + "/" + a.objectKey(name)
+	u.RawPath = escapePath(u.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.NopCloser(f))
+	if err != nil {
+		return logger.Permanent(err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sha))
+	a.sign(req, logger.CurrentClock().Now().UTC())
+
+	resp, err := a.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("s3archive: upload of %s failed: %s: %s", name, resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return err
+	}
+	return logger.Permanent(err)
+}
+
+// fileDigests returns the MD5 and SHA-256 digests and the size of r.
+func fileDigests(r io.Reader) (md5sum, sha []byte, size int64, err error) {
+	m, s := md5.New(), sha256.New()
+	size, err = io.Copy(io.MultiWriter(m, s), r)
+	return m.Sum(nil), s.Sum(nil), size, err
+}
+
+// sign adds AWS Signature Version 4 headers to req, whose
+// X-Amz-Content-Sha256 header must already be set.
+func (a *Archiver) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := day + "/" + a.cfg.Region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+a.cfg.SecretAccessKey), day)
+	key = hmacSHA256(key, a.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+a.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath URI-encodes p as required by Signature Version 4, leaving
+// only unreserved characters and slashes unescaped.
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package s3archive
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/73ddy-io/logger"
+)
+
+// fakeS3 is a minimal S3 endpoint storing the objects it receives. It
+// checks the signature headers and the Content-MD5 of every upload.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+	fails   int // requests left to reject with 503
+	puts    int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	s := &fakeS3{objects: make(map[string]string)}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.puts++
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Date") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	if s.fails > 0 {
+		s.fails--
+		http.Error(w, "SlowDown", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sum := md5.Sum(body)
+	if r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+		http.Error(w, "BadDigest", http.StatusBadRequest)
+		return
+	}
+	s.objects[r.URL.Path] = string(body)
+}
+
+func (s *fakeS3) object(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, ok := s.objects["/logs/"+key]
+	return body, ok
+}
+
+// instantClock is a logger.Clock stopped at a fixed time whose timers fire
+// immediately, so retries do not wait.
+type instantClock struct {
+	now time.Time
+}
+
+func (c instantClock) Now() time.Time { return c.now }
+
+func (c instantClock) NewTimer(time.Duration) logger.ClockTimer {
+	t := instantTimer{make(chan time.Time, 1)}
+	t.ch <- c.now
+	return t
+}
+
+func (c instantClock) AfterFunc(_ time.Duration, f func()) logger.ClockTimer {
+	go f()
+	return instantTimer{}
+}
+
+type instantTimer struct {
+	ch chan time.Time
+}
+
+func (t instantTimer) C() <-chan time.Time { return t.ch }
+func (t instantTimer) Stop() bool          { return false }
+
+func newTestArchiver(t *testing.T, endpoint string, cfg Config) *Archiver {
+	t.Helper()
+	logger.SetClock(instantClock{time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC)})
+	t.Cleanup(func() { logger.SetClock(nil) })
+
+	cfg.Endpoint = endpoint
+	cfg.Region = "us-east-1"
+	cfg.Bucket = "logs"
+	cfg.AccessKeyID = "AKID"
+	cfg.SecretAccessKey = "secret"
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUploadMarksFile(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	a := newTestArchiver(t, endpoint, Config{KeyPrefix: "app/{date}"})
+	name := filepath.Join(t.TempDir(), "app-2026-04-02T09-00-00.000.log.gz")
+	writeFile(t, name, "rotated entries")
+
+	if err := a.Upload(context.Background(), name); err != nil {
+		t.Fatal(err)
+	}
+	if body, ok := s3.object("app/2026-04-02/" + filepath.Base(name)); !ok || body != "rotated entries" {
+		t.Errorf("object = %q, %v; want the file contents", body, ok)
+	}
+	if _, err := os.Stat(name + uploadedSuffix); err != nil {
+		t.Errorf("no upload marker: %v", err)
+	}
+}
+
+func TestUploadRetries(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	s3.fails = 2
+	a := newTestArchiver(t, endpoint, Config{DeleteAfterUpload: true})
+	name := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, name, "entries")
+
+	if err := a.Upload(context.Background(), name); err != nil {
+		t.Fatal(err)
+	}
+	if s3.puts != 3 {
+		t.Errorf("%d requests, want 3", s3.puts)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("uploaded file was not deleted: %v", err)
+	}
+}
+
+func TestUploadPermanentFailure(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	a := newTestArchiver(t, endpoint, Config{})
+	a.cfg.AccessKeyID = "WRONG"
+	name := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, name, "entries")
+
+	if err := a.Upload(context.Background(), name); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Upload = %v, want the 403 response", err)
+	}
+	if s3.puts != 1 {
+		t.Errorf("%d requests, want a rejected upload not to be retried", s3.puts)
+	}
+}
+
+func TestUploadMarkerMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	_, endpoint := newFakeS3(t)
+	a := newTestArchiver(t, endpoint, Config{})
+	name := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, name, "entries")
+	if err := os.Chmod(name, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Upload(context.Background(), name); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name + uploadedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("marker has mode %v, want the mode of the uploaded file", info.Mode().Perm())
+	}
+}
+
+func TestFailedUploadRetriedOnRotation(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	s3.fails = 6 // the upload and its immediate retry, 3 attempts each
+	a := newTestArchiver(t, endpoint, Config{Retry: &logger.RetryPolicy{MaxAttempts: 3}})
+	dir := t.TempDir()
+	first := filepath.Join(dir, "app-1.log")
+	second := filepath.Join(dir, "app-2.log")
+	writeFile(t, first, "first")
+	writeFile(t, second, "second")
+
+	hook := a.Hook()
+	hook(first, "")
+	if _, ok := s3.object("app-1.log"); ok {
+		t.Fatal("upload succeeded although the service was unavailable")
+	}
+	hook(second, "")
+	for _, key := range []string{"app-1.log", "app-2.log"} {
+		if _, ok := s3.object(key); !ok {
+			t.Errorf("%s was not uploaded after the next rotation", key)
+		}
+	}
+}
+
+func TestResumeAfterCrash(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	dir := t.TempDir()
+	done := filepath.Join(dir, "app-2026-04-01T00-00-00.000.log.gz")
+	pending := filepath.Join(dir, "app-2026-04-02T00-00-00.000.log.gz")
+	writeFile(t, done, "uploaded before the crash")
+	writeFile(t, done+uploadedSuffix, "")
+	writeFile(t, pending, "rotated just before the crash")
+	writeFile(t, pending+".enc.tmp", "encryption interrupted by the crash")
+
+	// The process crashed before the hook uploaded pending; a new one
+	// scans for leftovers at startup.
+	a := newTestArchiver(t, endpoint, Config{})
+	if err := a.ResumePending(context.Background(), filepath.Join(dir, "app-*.log*")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s3.object(filepath.Base(done)); ok {
+		t.Error("file with an upload marker was uploaded again")
+	}
+	if body, ok := s3.object(filepath.Base(pending)); !ok || body != "rotated just before the crash" {
+		t.Errorf("pending object = %q, %v", body, ok)
+	}
+	if _, ok := s3.object(filepath.Base(pending) + ".enc.tmp"); ok {
+		t.Error("temporary file of an unfinished encryption was uploaded")
+	}
+	if s3.puts != 1 {
+		t.Errorf("%d requests, want 1", s3.puts)
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithSanitizer escapes control characters and repairs invalid UTF-8 in
+// messages before they are formatted, so that raw bytes from the network
+// cannot corrupt terminals or break line-oriented parsers.
+//
+// C0 control characters, including newlines and carriage returns, and DEL
+// are written as \xNN escapes; tabs are kept. C1 control characters are
+// written as \u00NN escapes, and every invalid UTF-8 sequence is replaced
+// with U+FFFD. The result is always valid UTF-8 on a single line. Messages
+// that need no changes are used as they are, without allocating.
+func WithSanitizer() Option {
+	return func(c *config) {
+		c.sanitize = true
+	}
+}
+
+// sanitize returns s with control characters escaped and invalid UTF-8
+// replaced; see WithSanitizer.
+func sanitize(s string) string {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if needsEscape(rune(c)) {
+				break
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || needsEscape(r) {
+			break
+		}
+		i += size
+	}
+	if i == len(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	b.WriteString(s[:i])
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case needsEscape(r) && r < 0x80:
+			b.WriteString(`\x`)
+			writeHex2(&b, byte(r))
+		case needsEscape(r):
+			b.WriteString(`\u00`)
+			writeHex2(&b, byte(r))
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// needsEscape reports whether r is a control character escaped by
+// sanitize.
+func needsEscape(r rune) bool {
+	return (r < 0x20 && r != '\t') || r == 0x7f || (r >= 0x80 && r <= 0x9f)
+}
+
+// writeHex2 writes c as two lowercase hexadecimal digits.
+func writeHex2(b *strings.Builder, c byte) {
+	if c < 0x10 {
+		b.WriteByte('0')
+	}
+	b.WriteString(strconv.FormatUint(uint64(c), 16))
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitize(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"plain ascii\twith tab", "plain ascii\twith tab"},
+		{"héllo wörld", "héllo wörld"},
+		{"two\nlines\r", `two\x0alines\x0d`},
+		{"\x1b[31mred\x1b[0m", `\x1b[31mred\x1b[0m`},
+		{"nul\x00del\x7f", `nul\x00del\x7f`},
+		{"c1 \u0085 next line", `c1 \u0085 next line`},
+		{"bad \xff\xfe utf8", "bad �� utf8"},
+	} {
+		if got := sanitize(tt.in); got != tt.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeCleanDoesNotAllocate(t *testing.T) {
+	msg := "GET /api/v1/users?id=42 200 1.5ms"
+	if n := testing.AllocsPerRun(100, func() { sanitize(msg) }); n != 0 {
+		t.Errorf("sanitize allocated %v times for a clean message", n)
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	for _, seed := range []string{"", "clean", "a\nb", "\x1b[2J", "\xc3\x28", "\u0085\u009f", "\t\x00\x7f"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		out := sanitize(in)
+		if !utf8.ValidString(out) {
+			t.Fatalf("sanitize(%q) = %q is not valid UTF-8", in, out)
+		}
+		for _, r := range out {
+			if needsEscape(r) {
+				t.Fatalf("sanitize(%q) = %q contains control character %U", in, out, r)
+			}
+		}
+		if strings.ContainsAny(out, "\n\r") {
+			t.Fatalf("sanitize(%q) = %q spans several lines", in, out)
+		}
+		if sanitize(out) != out {
+			t.Fatalf("sanitize(%q) = %q is not stable", in, out)
+		}
+	})
+}
+
+func FuzzSanitizedLine(f *testing.F) {
+	initTestLogger(f, "fuzz.log", WithSanitizer())
+	for _, seed := range []string{"request done", "multi\nline\nmessage", "\x1b]0;title\x07", "\xed\xa0\x80"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, msg string) {
+		mu.Lock()
+		line := string(encodeEntry(&Entry{Time: clockNow(), Level: WARN, Message: msg}))
+		mu.Unlock()
+
+		if !utf8.ValidString(line) {
+			t.Fatalf("line %q is not valid UTF-8", line)
+		}
+		if strings.IndexAny(line, "\r\n") != len(line)-1 {
+			t.Fatalf("line %q is not a single line", line)
+		}
+		if _, err := ParseLine(strings.TrimSuffix(line, "\n")); err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+	})
+}
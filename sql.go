@@ -0,0 +1,445 @@
+package logger
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLArgsMode selects how query arguments are logged by WrapDriver.
+type SQLArgsMode int
+
+// Available argument logging modes.
+//
+// SQLArgsOff does not log arguments.
+// SQLArgsRedacted logs the type of each argument but not its value.
+// SQLArgsFull logs argument values; it should not be used when queries may
+// carry credentials or personal data.
+const (
+	SQLArgsOff SQLArgsMode = iota
+	SQLArgsRedacted
+	SQLArgsFull
+)
+
+// SQLOption configures WrapDriver.
+type SQLOption func(c *sqlConfig)
+
+type sqlConfig struct {
+	slow time.Duration
+	args SQLArgsMode
+}
+
+// WithSlowQueryThreshold makes queries taking longer than d log at WARN.
+// The default is 200ms.
+func WithSlowQueryThreshold(d time.Duration) SQLOption {
+	return func(c *sqlConfig) {
+		c.slow = d
+	}
+}
+
+// WithSQLArgs sets how query arguments are logged. The default is
+// SQLArgsOff.
+func WithSQLArgs(mode SQLArgsMode) SQLOption {
+	return func(c *sqlConfig) {
+		c.args = mode
+	}
+}
+
+// WrapDriver returns a database/sql driver that logs every query and
+// statement execution of d, with its duration, the number of affected rows
+// when known, and the error if any.
+//
+// Queries are logged at DEBUG, or at WARN when slower than the slow query
+// threshold; failures are logged at ERROR, and queries aborted by context
+// cancellation at WARN. Register the result under its own name:
+//
+//	sql.Register("postgres-logged", logger.WrapDriver(&pq.Driver{}))
+//
+// The wrapper forwards the optional driver interfaces (QueryerContext,
+// ExecerContext, ConnBeginTx, SessionResetter, Pinger, ...) of the wrapped
+// connections, falling back to what database/sql would do when the
+// underlying driver does not implement them.
+func WrapDriver(d driver.Driver, opts ...SQLOption) driver.Driver {
+	c := sqlConfig{slow: 200 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &sqlDriver{d: d, cfg: c}
+}
+
+type sqlDriver struct {
+	d   driver.Driver
+	cfg sqlConfig
+}
+
+// Open implements driver.Driver.
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{c: c, cfg: &d.cfg}, nil
+}
+
+// OpenConnector implements driver.DriverContext.
+func (d *sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlConnector{c: c, d: d}, nil
+	}
+	return &sqlConnector{name: name, d: d}, nil
+}
+
+type sqlConnector struct {
+	c    driver.Connector
+	name string
+	d    *sqlDriver
+}
+
+// Connect implements driver.Connector.
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.c == nil {
+		return c.d.Open(c.name)
+	}
+	conn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{c: conn, cfg: &c.d.cfg}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.d
+}
+
+type sqlConn struct {
+	c   driver.Conn
+	cfg *sqlConfig
+}
+
+// Prepare implements driver.Conn.
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.c.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		if err = ctx.Err(); err == nil {
+			s, err = c.c.Prepare(query)
+		}
+	}
+	if err != nil {
+		c.cfg.log("prepare", query, nil, time.Time{}, -1, err)
+		return nil, err
+	}
+	return &sqlStmt{s: s, conn: c, query: query}, nil
+}
+
+// Close implements driver.Conn.
+func (c *sqlConn) Close() error {
+	return c.c.Close()
+}
+
+// Begin implements driver.Conn.
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if bt, ok := c.c.(driver.ConnBeginTx); ok {
+		tx, err = bt.BeginTx(ctx, opts)
+	} else if opts.Isolation != 0 || opts.ReadOnly {
+		err = errors.New("sql: driver does not support non-default transaction options")
+	} else if err = ctx.Err(); err == nil {
+		tx, err = c.c.Begin()
+	}
+	if err != nil {
+		c.cfg.log("begin", "", nil, time.Time{}, -1, err)
+		return nil, err
+	}
+	return &sqlTx{tx: tx, cfg: c.cfg}, nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := clockNow()
+	var rows driver.Rows
+	var err error
+	switch q := c.c.(type) {
+	case driver.QueryerContext:
+		rows, err = q.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			rows, err = q.Query(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	if err != driver.ErrSkip {
+		c.cfg.log("query", query, args, start, -1, err)
+	}
+	return rows, err
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := clockNow()
+	var res driver.Result
+	var err error
+	switch e := c.c.(type) {
+	case driver.ExecerContext:
+		res, err = e.ExecContext(ctx, query, args)
+	case driver.Execer:
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			res, err = e.Exec(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	if err != driver.ErrSkip {
+		c.cfg.log("exec", query, args, start, rowsAffected(res, err), err)
+	}
+	return res, err
+}
+
+// Ping implements driver.Pinger.
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.c.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.c.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.c.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.c.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type sqlStmt struct {
+	s     driver.Stmt
+	conn  *sqlConn
+	query string
+}
+
+// Close implements driver.Stmt.
+func (s *sqlStmt) Close() error {
+	return s.s.Close()
+}
+
+// NumInput implements driver.Stmt.
+func (s *sqlStmt) NumInput() int {
+	return s.s.NumInput()
+}
+
+// Exec implements driver.Stmt.
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+// Query implements driver.Stmt.
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := clockNow()
+	var res driver.Result
+	var err error
+	if e, ok := s.s.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				res, err = s.s.Exec(values)
+			}
+		}
+	}
+	s.conn.cfg.log("exec", s.query, args, start, rowsAffected(res, err), err)
+	return res, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := clockNow()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.s.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedToValues(args); err == nil {
+			if err = ctx.Err(); err == nil {
+				rows, err = s.s.Query(values)
+			}
+		}
+	}
+	s.conn.cfg.log("query", s.query, args, start, -1, err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, deferring to the
+// statement or its connection like database/sql does.
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.s.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// ColumnConverter implements driver.ColumnConverter.
+func (s *sqlStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.s.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+type sqlTx struct {
+	tx  driver.Tx
+	cfg *sqlConfig
+}
+
+// Commit implements driver.Tx.
+func (t *sqlTx) Commit() error {
+	start := clockNow()
+	err := t.tx.Commit()
+	t.cfg.log("commit", "", nil, start, -1, err)
+	return err
+}
+
+// Rollback implements driver.Tx.
+func (t *sqlTx) Rollback() error {
+	start := clockNow()
+	err := t.tx.Rollback()
+	t.cfg.log("rollback", "", nil, start, -1, err)
+	return err
+}
+
+// log writes the entry for a database operation. A zero start omits the
+// duration, and a negative rows omits the affected row count.
+func (c *sqlConfig) log(op, query string, args []driver.NamedValue, start time.Time, rows int64, err error) {
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = clockSince(start)
+	}
+
+	level := DEBUG
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		level = WARN
+	case err != nil:
+		level = ERROR
+	case c.slow > 0 && elapsed > c.slow:
+		level = WARN
+	}
+	if !enabled(level, 1) {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("SQL " + op)
+	if !start.IsZero() {
+		fmt.Fprintf(&b, " (%v", elapsed.Round(time.Microsecond))
+		if rows >= 0 {
+			fmt.Fprintf(&b, ", %d rows", rows)
+		}
+		b.WriteByte(')')
+	}
+	if query != "" {
+		b.WriteString(": " + query)
+	}
+	if len(args) > 0 && c.args != SQLArgsOff {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			if c.args == SQLArgsFull {
+				parts[i] = fmt.Sprintf("%#v", arg.Value)
+			} else {
+				parts[i] = fmt.Sprintf("<%T>", arg.Value)
+			}
+		}
+		b.WriteString(" args=[" + strings.Join(parts, ", ") + "]")
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		b.WriteString(" canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		b.WriteString(" timed out")
+	case err != nil:
+		b.WriteString(" failed: " + err.Error())
+	}
+
+	write(newEntry(clockNow(), level, 1, b.String()))
+}
+
+// rowsAffected returns the number of rows affected by res, or -1 if it is
+// not known.
+func rowsAffected(res driver.Result, err error) int64 {
+	if err != nil || res == nil {
+		return -1
+	}
+	n, rerr := res.RowsAffected()
+	if rerr != nil {
+		return -1
+	}
+	return n
+}
+
+// namedToValues converts named arguments for drivers that only support
+// positional ones.
+func namedToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// valuesToNamed converts positional arguments to named ones.
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
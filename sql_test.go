@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeDriver is an in-memory database/sql driver. Statements report one
+// affected row and queries return no rows; the query "SELECT sleep"
+// blocks until its context is done.
+type fakeDriver struct {
+	started chan struct{}
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query != "SELECT sleep" {
+		return fakeRows{}, nil
+	}
+	c.d.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"n"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+// openFakeDB returns a database using the fake driver wrapped with opts,
+// and the driver.
+func openFakeDB(t *testing.T, opts ...SQLOption) (*sql.DB, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{started: make(chan struct{}, 1)}
+	c, err := WrapDriver(d, opts...).(driver.DriverContext).OpenConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(c)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestSQLPreparedStatement(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 2, 1, 9, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+	db, _ := openFakeDB(t, WithSQLArgs(SQLArgsFull))
+
+	stmt, err := db.Prepare("INSERT INTO t VALUES (?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec(42); err != nil {
+		t.Fatal(err)
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	want := "SQL exec (0s, 1 rows): INSERT INTO t VALUES (?) args=[42]"
+	if len(es) != 1 || es[0].Message != want || es[0].Level != DEBUG {
+		t.Fatalf("logged %+v, want one DEBUG entry %q", es, want)
+	}
+}
+
+func TestSQLTransaction(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 2, 1, 9, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+	db, _ := openFakeDB(t, WithSQLArgs(SQLArgsRedacted))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("UPDATE t SET n = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"SQL exec (0s, 1 rows): UPDATE t SET n = ? args=[<int64>]",
+		"SQL commit (0s)",
+		"SQL rollback (0s)",
+	}
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	if len(es) != len(want) {
+		t.Fatalf("logged %d entries, want %d: %+v", len(es), len(want), es)
+	}
+	for i, e := range es {
+		if e.Message != want[i] || e.Level != DEBUG {
+			t.Errorf("entry %d = %v %q, want DEBUG %q", i, e.Level, e.Message, want[i])
+		}
+	}
+}
+
+func TestSQLQueryCanceled(t *testing.T) {
+	useFakeClock(t, time.Date(2026, 2, 1, 9, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+	db, d := openFakeDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-d.started
+		cancel()
+	}()
+	if _, err := db.QueryContext(ctx, "SELECT sleep"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("QueryContext returned %v, want context.Canceled", err)
+	}
+
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	want := "SQL query (0s): SELECT sleep canceled"
+	if len(es) != 1 || es[0].Message != want || es[0].Level != WARN {
+		t.Fatalf("logged %+v, want one WARN entry %q", es, want)
+	}
+}
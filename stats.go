@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the logger's counters.
+type Stats struct {
+	// Dropped is the number of entries discarded because they were
+	// logged after Close.
+	Dropped uint64
+
+	// FollowDropped is the number of entries Follow could not deliver
+	// because the consumer did not keep up.
+	FollowDropped uint64
+
+	// DiskDropped is the number of entries discarded because free disk
+	// space fell below the hard floor of the disk guard.
+	DiskDropped uint64
+
+	// Batches is the number of batches all Batchers flushed successfully,
+	// and BatchedEntries the number of entries they contained.
+	Batches        uint64
+	BatchedEntries uint64
+
+	// BatchDropped is the number of entries in batches whose flush failed.
+	BatchDropped uint64
+
+	// BatchFlushTime is the total time spent in Batcher flush functions.
+	BatchFlushTime time.Duration
+
+	// Retries is the number of deliveries retried by a RetryPolicy.
+	Retries uint64
+
+	// Logged holds the number of entries logged at every level, indexed
+	// by level - DEBUG, since start or the last ResetErrorCount.
+	Logged [numLevels]int64
+}
+
+var dropped atomic.Uint64
+
+// CurrentStats returns a snapshot of the logger's counters.
+func CurrentStats() Stats {
+	s := Stats{
+		Dropped:       dropped.Load(),
+		FollowDropped: followDropped.Load(),
+		DiskDropped:   diskDropped.Load(),
+
+		Batches:        batchesSent.Load(),
+		BatchedEntries: batchedEntries.Load(),
+		BatchDropped:   batchDropped.Load(),
+		BatchFlushTime: time.Duration(batchFlushTime.Load()),
+		Retries:        retries.Load(),
+	}
+	for l := range s.Logged {
+		s.Logged[l] = levelCounts[l].Load()
+	}
+	return s
+}
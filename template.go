@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time layouts used by the {date} and {time} filename placeholders.
+const (
+	templateDateLayout = "2006-01-02"
+	templateTimeLayout = "150405"
+)
+
+// filenameTemplate is a log filename with its placeholders resolved.
+//
+// The filename passed to InitLogger may contain the following placeholders:
+//
+//	{date}     current date, as 2006-01-02
+//	{time}     current time, as 150405
+//	{host}     host name
+//	{pid}      process ID
+//	{env:VAR}  value of the environment variable VAR
+//
+// {host}, {pid} and {env:VAR} are resolved once when the template is
+// parsed. {date} and {time} are resolved every time a file is opened, so
+// files created later get names reflecting the moment they were created.
+type filenameTemplate struct {
+	parts []templatePart
+}
+
+// templatePart is either literal text or a time layout to be rendered when
+// the template is expanded.
+type templatePart struct {
+	text   string
+	layout string
+}
+
+// parseFilenameTemplate parses name and resolves its static placeholders.
+//
+// Unknown placeholders, unterminated braces and unset environment variables
+// are reported as errors rather than ending up literally in the filename.
+func parseFilenameTemplate(name string) (*filenameTemplate, error) {
+	t := &filenameTemplate{}
+	rest := name
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			t.addText(rest)
+			return t, nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid filename template %q: unterminated placeholder", name)
+		}
+		t.addText(rest[:start])
+
+		token := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		switch {
+		case token == "date":
+			t.parts = append(t.parts, templatePart{layout: templateDateLayout})
+		case token == "time":
+			t.parts = append(t.parts, templatePart{layout: templateTimeLayout})
+		case token == "host":
+			host, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("invalid filename template %q: %w", name, err)
+			}
+			t.addText(sanitizePathElement(host))
+		case token == "pid":
+			t.addText(strconv.Itoa(os.Getpid()))
+		case strings.HasPrefix(token, "env:"):
+			value, ok := os.LookupEnv(token[len("env:"):])
+			if !ok {
+				return nil, fmt.Errorf("invalid filename template %q: environment variable %s is not set", name, token[len("env:"):])
+			}
+			t.addText(sanitizePathElement(value))
+		default:
+			return nil, fmt.Errorf("invalid filename template %q: unknown placeholder {%s}", name, token)
+		}
+	}
+}
+
+// addText appends literal text to the template.
+func (t *filenameTemplate) addText(s string) {
+	if s != "" {
+		t.parts = append(t.parts, templatePart{text: s})
+	}
+}
+
+// expand renders the template for a file opened at now.
+func (t *filenameTemplate) expand(now time.Time) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.layout != "" {
+			b.WriteString(now.Format(p.layout))
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
+
+// dated reports whether the template contains the {date} placeholder.
+func (t *filenameTemplate) dated() bool {
+	for _, p := range t.parts {
+		if p.layout == templateDateLayout {
+			return true
+		}
+	}
+	return false
+}
+
+// stable renders the template with the {date} and {time} placeholders
+// removed, giving a name that does not change over the process lifetime.
+func (t *filenameTemplate) stable() string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
+
+// sanitizePathElement replaces path separators in a value substituted into
+// a filename, so it cannot introduce extra directories.
+func sanitizePathElement(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilenameTemplateExpand(t *testing.T) {
+	t.Setenv("LOG_ROLE", "worker/1")
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := parseFilenameTemplate("logs/{env:LOG_ROLE}-{host}-{pid}-{date}T{time}.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 9, 5, 7, 0, time.Local)
+	want := "logs/worker_1-" + sanitizePathElement(host) + "-" + strconv.Itoa(os.Getpid()) + "-2026-03-01T090507.log"
+	if got := tmpl.expand(now); got != want {
+		t.Errorf("expand = %q, want %q", got, want)
+	}
+	if got := tmpl.stable(); strings.ContainsAny(got, "{}") || !strings.HasSuffix(got, "-T.log") {
+		t.Errorf("stable = %q", got)
+	}
+
+	for _, name := range []string{"app-{datee}.log", "app-{date.log", "app-{env:LOG_UNSET_VAR}.log"} {
+		if _, err := parseFilenameTemplate(name); err == nil {
+			t.Errorf("parseFilenameTemplate(%q) succeeded", name)
+		}
+	}
+}
+
+// TestFilenameTemplateInstancesDiffer checks that instances sharing a
+// template and a directory write to distinct files, and that the {time}
+// placeholder gives every second its own name.
+func TestFilenameTemplateInstancesDiffer(t *testing.T) {
+	dir := t.TempDir()
+	for _, instance := range []string{"a", "b/c"} {
+		t.Setenv("INSTANCE", instance)
+		if err := InitLogger(filepath.Join(dir, "app-{env:INSTANCE}-{pid}.log")); err != nil {
+			t.Fatal(err)
+		}
+		Info("instance %s", instance)
+		Close()
+	}
+	for _, tt := range []struct{ name, want string }{
+		{"app-a-" + strconv.Itoa(os.Getpid()) + ".log", " - instance a"},
+		{"app-b_c-" + strconv.Itoa(os.Getpid()) + ".log", " - instance b/c"},
+	} {
+		lines := readLines(t, filepath.Join(dir, tt.name))
+		if len(lines) != 1 || !strings.HasSuffix(lines[0], tt.want) {
+			t.Errorf("%s holds %q, want one line ending in %q", tt.name, lines, tt.want)
+		}
+	}
+
+	now := time.Date(2026, 3, 1, 9, 5, 7, 0, time.Local)
+	tmpl, err := parseFilenameTemplate("app-{date}-{time}.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		name := tmpl.expand(now.Add(time.Duration(i) * time.Second))
+		if seen[name] {
+			t.Errorf("%q expanded twice", name)
+		}
+		seen[name] = true
+	}
+}
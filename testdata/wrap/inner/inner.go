@@ -0,0 +1,10 @@
+// Package inner is the innermost of two nested logging wrappers used by
+// the caller resolution tests.
+package inner
+
+import "github.com/73ddy-io/logger"
+
+// Info logs msg through the logger.
+func Info(msg string) {
+	logger.Info("%s", msg)
+}
@@ -0,0 +1,10 @@
+// Package outer wraps package inner, so that entries logged through it
+// pass two wrapper levels.
+package outer
+
+import "github.com/73ddy-io/logger/testdata/wrap/inner"
+
+// Info logs msg through inner.Info.
+func Info(msg string) {
+	inner.Info(msg)
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timer measures the duration of an operation and logs it when stopped.
+// It is created with StartTimer.
+type Timer struct {
+	name      string
+	start     time.Time
+	warnAfter time.Duration
+	site      callSite
+}
+
+// StartTimer starts timing the operation name. Stopping the timer logs a
+// single entry attributed to the function that called StartTimer, which
+// makes it convenient to defer:
+//
+//	defer logger.StartTimer("rebuild index").Stop()
+func StartTimer(name string) *Timer {
+	return &Timer{
+		name:  name,
+		start: clockNow(),
+		site:  captureCallSite(1),
+	}
+}
+
+// WarnAfter makes Stop log at WARN instead of INFO when the operation took
+// longer than d. It returns t to allow chaining after StartTimer.
+func (t *Timer) WarnAfter(d time.Duration) *Timer {
+	t.warnAfter = d
+	return t
+}
+
+// Stop logs the elapsed time since StartTimer, both as a human readable
+// duration and in milliseconds, and returns it.
+func (t *Timer) Stop() time.Duration {
+	now := clockNow()
+	elapsed := now.Sub(t.start)
+
+	level := INFO
+	if t.warnAfter > 0 && elapsed > t.warnAfter {
+		level = WARN
+	}
+	if enabledAt(level, t.site.pc) {
+		message := fmt.Sprintf("%s took %v elapsed_ms=%d", t.name, elapsed.Round(time.Microsecond), elapsed.Milliseconds())
+		write(entryAt(now, level, t.site, message))
+	}
+	return elapsed
+}
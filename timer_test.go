@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTimerUsesClock(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 9, 1, 8, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	timer := StartTimer("rebuild index").WarnAfter(time.Second)
+	clock.Advance(750*time.Millisecond + 250*time.Microsecond)
+	if got := timer.Stop(); got != 750*time.Millisecond+250*time.Microsecond {
+		t.Errorf("Stop = %v, want 750.25ms", got)
+	}
+
+	slow := StartTimer("compact")
+	slow.WarnAfter(time.Second)
+	clock.Advance(2500 * time.Millisecond)
+	slow.Stop()
+
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	want := []struct {
+		level LogLevel
+		msg   string
+	}{
+		{INFO, "rebuild index took 750.25ms elapsed_ms=750"},
+		{WARN, "compact took 2.5s elapsed_ms=2500"},
+	}
+	if len(es) != len(want) {
+		t.Fatalf("logged %d entries, want %d", len(es), len(want))
+	}
+	for i, e := range es {
+		if e.Level != want[i].level || e.Message != want[i].msg {
+			t.Errorf("entry %d = %v %q, want %v %q", i, e.Level, e.Message, want[i].level, want[i].msg)
+		}
+		if e.Func != "TestTimerUsesClock" {
+			t.Errorf("entry %d attributed to %s, want the caller of StartTimer", i, e.Func)
+		}
+	}
+	if !es[1].Time.Equal(clock.Now().Truncate(time.Second)) {
+		t.Errorf("entry logged at %v, want the time of Stop %v", es[1].Time, clock.Now())
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// noopTrace is returned by TraceFunc when DEBUG is disabled.
+func noopTrace() {}
+
+// TraceFunc logs the entry into the calling function at DEBUG and returns
+// a function logging its exit together with the elapsed time:
+//
+//	func (db *DB) Get(key string) {
+//		defer logger.TraceFunc(key)()
+//		...
+//	}
+//
+// writes "→ storage.(*DB).Get(user:42)" on entry and
+// "← storage.(*DB).Get (took 1.2ms)" on return. Both entries are
+// attributed to the traced function. The optional args are rendered with
+// fmt.Sprint, separated by commas. When DEBUG is disabled for the calling
+// package, TraceFunc does nothing.
+func TraceFunc(args ...interface{}) func() {
+	site := captureCallSite(1)
+	if !enabledAt(DEBUG, site.pc) {
+		return noopTrace
+	}
+
+	name := tracedFuncName(site.pc)
+	start := clockNow()
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprint(arg)
+	}
+	write(entryAt(start, DEBUG, site, "→ "+name+"("+strings.Join(parts, ", ")+")"))
+
+	return func() {
+		now := clockNow()
+		exit := site
+		if _, _, line, ok := runtime.Caller(1); ok {
+			exit.line = line
+		}
+		write(entryAt(now, DEBUG, exit, fmt.Sprintf("← %s (took %v)", name, now.Sub(start).Round(time.Microsecond))))
+	}
+}
+
+// tracedFuncName returns the name of the function at pc qualified with its
+// package name, such as "storage.(*DB).Get".
+func tracedFuncName(pc uintptr) string {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	name := frame.Function
+	if slash := strings.LastIndexByte(name, '/'); slash >= 0 {
+		name = name[slash+1:]
+	}
+	return name
+}
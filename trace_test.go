@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tracedStep is a traced function calling itself depth times.
+func tracedStep(clock *fakeClock, depth int) {
+	defer TraceFunc(depth)()
+	clock.Advance(time.Millisecond)
+	if depth > 0 {
+		tracedStep(clock, depth-1)
+	}
+}
+
+func TestTraceFuncPairsEntryAndExit(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 9, 1, 8, 0, 0, 0, time.Local))
+	dir := initTestLogger(t, "app.log")
+
+	tracedStep(clock, 2)
+
+	es := readEntries(t, filepath.Join(dir, "app.log"))
+	want := []string{
+		"→ logger.tracedStep(2)",
+		"→ logger.tracedStep(1)",
+		"→ logger.tracedStep(0)",
+		"← logger.tracedStep (took 1ms)",
+		"← logger.tracedStep (took 2ms)",
+		"← logger.tracedStep (took 3ms)",
+	}
+	if len(es) != len(want) {
+		t.Fatalf("logged %d entries, want %d: %+v", len(es), len(want), es)
+	}
+	var stack []Entry
+	for i, e := range es {
+		if e.Message != want[i] || e.Level != DEBUG {
+			t.Errorf("entry %d = %v %q, want DEBUG %q", i, e.Level, e.Message, want[i])
+		}
+		if e.Func != "tracedStep" || e.File != "trace_test.go" {
+			t.Errorf("entry %d attributed to %s:%d %s, want tracedStep", i, e.File, e.Line, e.Func)
+		}
+		if strings.HasPrefix(e.Message, "→") {
+			stack = append(stack, e)
+			continue
+		}
+		if len(stack) == 0 {
+			t.Fatalf("exit %d without an entry", i)
+		}
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) != 0 {
+		t.Errorf("%d entries without an exit", len(stack))
+	}
+}
+
+func TestTraceFuncDisabled(t *testing.T) {
+	dir := initTestLogger(t, "app.log")
+	SetLevel(INFO)
+
+	func() {
+		defer TraceFunc("ignored")()
+	}()
+	Info("marker")
+
+	if got := messages(t, filepath.Join(dir, "app.log")); len(got) != 1 || got[0] != "marker" {
+		t.Errorf("logged %q with DEBUG disabled", got)
+	}
+}
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TriggerBuffer configures the trigger buffer enabled with
+// WithTriggerBuffer.
+type TriggerBuffer struct {
+	// Size is the number of entries kept per request; older entries are
+	// discarded first. It defaults to 100.
+	Size int
+
+	// MaxAge discards entries held for longer, and the buffers of
+	// requests that logged nothing for that long. It defaults to one
+	// minute.
+	MaxAge time.Duration
+
+	// MaxRequests is the number of requests buffered at once. When a new
+	// request starts logging with all in use, the buffer of the request
+	// idle the longest is discarded. It defaults to 1000.
+	MaxRequests int
+
+	// Trigger is the level at which the held entries are written. The
+	// zero value, INFO, selects ERROR, since replaying on every INFO
+	// entry would defeat the buffer.
+	Trigger LogLevel
+}
+
+// WithTriggerBuffer keeps the entries rejected by the level threshold in
+// memory instead of discarding them, and writes them out ahead of the next
+// entry at the trigger level, so that an error comes with the DEBUG lines
+// leading up to it. Replayed entries keep their original time and caller
+// and their message is prefixed with "[replayed] ".
+//
+// Entries logged with DebugCtx and the other Ctx functions are buffered
+// per request, identified by the request ID attached with
+// ContextWithRequestID or else by the trace ID of ContextWithTrace, and an
+// entry at the trigger level only replays the entries of its own request.
+// Entries logged without a request share one buffer.
+func WithTriggerBuffer(b TriggerBuffer) Option {
+	return func(c *config) {
+		if b.Size <= 0 {
+			b.Size = 100
+		}
+		if b.MaxAge <= 0 {
+			b.MaxAge = time.Minute
+		}
+		if b.MaxRequests <= 0 {
+			b.MaxRequests = 1000
+		}
+		if b.Trigger == INFO {
+			b.Trigger = ERROR
+		}
+		c.triggerBuffer = &b
+	}
+}
+
+// heldEntries holds the entries waiting for a trigger: one ring shared by
+// the entries logged without a request and one per request.
+type heldEntries struct {
+	TriggerBuffer
+
+	mu        sync.Mutex
+	shared    heldRing
+	requests  map[string]*heldRing
+	lastSweep time.Time
+}
+
+// heldRing is a bounded ring of entries.
+type heldRing struct {
+	entries []Entry
+	start   int
+	count   int
+	last    time.Time // time of the newest entry
+}
+
+var held atomic.Pointer[heldEntries]
+
+// resetTriggerBuffer discards the held entries and sets up the buffer of
+// the current configuration, if any. The caller must hold mu.
+func resetTriggerBuffer() {
+	if cfg.triggerBuffer == nil {
+		held.Store(nil)
+		return
+	}
+	held.Store(&heldEntries{
+		TriggerBuffer: *cfg.triggerBuffer,
+		shared:        heldRing{entries: make([]Entry, cfg.triggerBuffer.Size)},
+		requests:      make(map[string]*heldRing),
+	})
+}
+
+// logAt writes an entry at level attributed to the caller skip frames above
+// the caller of logAt, building its message with msg only if the entry is
+// written or held in the trigger buffer.
+func logAt(level LogLevel, skip int, msg func() string) {
+	logCtxAt(context.Background(), level, skip+1, msg)
+}
+
+// logCtxAt is logAt for entries carrying the trace context of ctx.
+func logCtxAt(ctx context.Context, level LogLevel, skip int, msg func() string) {
+	ok := enabled(level, skip+1)
+	h := held.Load()
+	if !ok && h == nil {
+		return
+	}
+
+	e := newEntry(clockNow(), level, skip+1, msg())
+	e.TraceID, e.SpanID = TraceFromContext(ctx)
+	e.request = requestKey(ctx, e.TraceID)
+	if !ok {
+		h.hold(e)
+		return
+	}
+	write(e)
+}
+
+// hold adds e to the ring of its request, replacing the oldest entry
+// when it is full.
+func (h *heldEntries) hold(e Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sweep(e.Time)
+	r := &h.shared
+	if e.request != "" {
+		if r = h.requests[e.request]; r == nil {
+			if len(h.requests) >= h.MaxRequests {
+				h.evictIdlest()
+			}
+			r = &heldRing{entries: make([]Entry, h.Size)}
+			h.requests[e.request] = r
+		}
+	}
+	r.add(e, h.MaxAge)
+}
+
+// take removes and returns the entries held for the request of e. The
+// caller must hold h.mu.
+func (h *heldEntries) take(e *Entry) []Entry {
+	if e.request == "" {
+		return h.shared.take(e.Time, h.MaxAge)
+	}
+	r := h.requests[e.request]
+	if r == nil {
+		return nil
+	}
+	delete(h.requests, e.request)
+	return r.take(e.Time, h.MaxAge)
+}
+
+// sweep discards the rings of requests that logged nothing for MaxAge at
+// now. It scans the requests at most once per MaxAge. The caller must hold
+// h.mu.
+func (h *heldEntries) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < h.MaxAge {
+		return
+	}
+	h.lastSweep = now
+	for id, r := range h.requests {
+		if now.Sub(r.last) > h.MaxAge {
+			delete(h.requests, id)
+		}
+	}
+}
+
+// evictIdlest discards the ring of the request that logged nothing for
+// the longest time. The caller must hold h.mu.
+func (h *heldEntries) evictIdlest() {
+	var idlest string
+	var last time.Time
+	for id, r := range h.requests {
+		if idlest == "" || r.last.Before(last) {
+			idlest, last = id, r.last
+		}
+	}
+	delete(h.requests, idlest)
+}
+
+// add appends e to the ring after dropping the entries older than maxAge,
+// replacing the oldest entry when the ring is full.
+func (r *heldRing) add(e Entry, maxAge time.Duration) {
+	r.expire(e.Time, maxAge)
+	if r.count == len(r.entries) {
+		r.start = (r.start + 1) % len(r.entries)
+		r.count--
+	}
+	r.entries[(r.start+r.count)%len(r.entries)] = e
+	r.count++
+	r.last = e.Time
+}
+
+// take removes and returns the entries held at now that are not older
+// than maxAge, oldest first.
+func (r *heldRing) take(now time.Time, maxAge time.Duration) []Entry {
+	r.expire(now, maxAge)
+	entries := make([]Entry, 0, r.count)
+	for ; r.count > 0; r.count-- {
+		entries = append(entries, r.entries[r.start])
+		r.entries[r.start] = Entry{}
+		r.start = (r.start + 1) % len(r.entries)
+	}
+	return entries
+}
+
+// expire drops the entries held for longer than maxAge at now.
+func (r *heldRing) expire(now time.Time, maxAge time.Duration) {
+	for r.count > 0 && now.Sub(r.entries[r.start].Time) > maxAge {
+		r.entries[r.start] = Entry{}
+		r.start = (r.start + 1) % len(r.entries)
+		r.count--
+	}
+}
+
+// replayHeld writes the entries held for the request of e ahead of e if e
+// is at the trigger level. The caller must hold mu.
+func replayHeld(e *Entry) {
+	h := held.Load()
+	if h == nil || e.Level < h.Trigger {
+		return
+	}
+
+	h.mu.Lock()
+	replay := h.take(e)
+	h.mu.Unlock()
+
+	for i := range replay {
+		replay[i].Message = "[replayed] " + replay[i].Message
+		if err := emit(&replay[i]); err != nil {
+			writeFailures++
+			if writeErr == nil {
+				writeErr = err
+			}
+		}
+	}
+}
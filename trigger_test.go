@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// messages returns the messages of the entries in the log file at path.
+func messages(t *testing.T, path string) []string {
+	t.Helper()
+	var msgs []string
+	for _, line := range readLines(t, path) {
+		e, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", line, err)
+		}
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestTriggerBufferReplaysRequest(t *testing.T) {
+	dir := initTestLogger(t, "app.log", WithTriggerBuffer(TriggerBuffer{}))
+	SetLevel(INFO)
+
+	a := ContextWithRequestID(context.Background(), "a")
+	b := ContextWithRequestID(context.Background(), "b")
+	for i := 1; i <= 5; i++ {
+		DebugCtx(a, "a step %d", i)
+		DebugCtx(b, "b step %d", i)
+	}
+	ErrorCtx(a, "a failed")
+
+	want := []string{
+		"[replayed] a step 1",
+		"[replayed] a step 2",
+		"[replayed] a step 3",
+		"[replayed] a step 4",
+		"[replayed] a step 5",
+		"a failed",
+	}
+	if got := messages(t, filepath.Join(dir, "app.log")); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+
+	Error("unrelated failure")
+	ErrorCtx(b, "b failed")
+	got := messages(t, filepath.Join(dir, "app.log"))[len(want):]
+	if len(got) != 7 || got[0] != "unrelated failure" || got[1] != "[replayed] b step 1" || got[6] != "b failed" {
+		t.Errorf("logged %q after the first request", got)
+	}
+}
+
+// TestFailedInitKeepsTriggerBuffer checks that an InitLogger call failing
+// to open its file does not discard the entries held so far.
+func TestFailedInitKeepsTriggerBuffer(t *testing.T) {
+	dir := initTestLogger(t, "app.log", WithTriggerBuffer(TriggerBuffer{}))
+	SetLevel(INFO)
+
+	ctx := ContextWithRequestID(context.Background(), "a")
+	DebugCtx(ctx, "step 1")
+	if err := InitLogger(filepath.Join(dir, "app.log", "nested.log"), WithTriggerBuffer(TriggerBuffer{})); err == nil {
+		t.Fatal("InitLogger succeeded below a regular file")
+	}
+	DebugCtx(ctx, "step 2")
+	ErrorCtx(ctx, "failed")
+
+	want := []string{"[replayed] step 1", "[replayed] step 2", "failed"}
+	if got := messages(t, filepath.Join(dir, "app.log")); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestTriggerBufferSharedWithoutRequest(t *testing.T) {
+	dir := initTestLogger(t, "app.log", WithTriggerBuffer(TriggerBuffer{Size: 2}))
+	SetLevel(INFO)
+
+	traced := ContextWithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	DebugCtx(traced, "traced step")
+	for i := 1; i <= 3; i++ {
+		Debug("step %d", i)
+	}
+	Error("failed")
+
+	want := []string{"[replayed] step 2", "[replayed] step 3", "failed"}
+	if got := messages(t, filepath.Join(dir, "app.log")); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+
+func TestTriggerBufferDiscardsIdleRequests(t *testing.T) {
+	clock := useFakeClock(t, time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local))
+	initTestLogger(t, "app.log", WithTriggerBuffer(TriggerBuffer{MaxAge: time.Minute, MaxRequests: 2}))
+	SetLevel(INFO)
+
+	requests := func() []string {
+		h := held.Load()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		var ids []string
+		for id := range h.requests {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	debug := func(id string) {
+		DebugCtx(ContextWithRequestID(context.Background(), id), "working on %s", id)
+	}
+
+	debug("a")
+	clock.Advance(time.Second)
+	debug("b")
+	clock.Advance(time.Second)
+	debug("c")
+	if ids := fmt.Sprint(requests()); ids != "[b c]" && ids != "[c b]" {
+		t.Errorf("buffered requests %s, want the idlest one evicted", ids)
+	}
+
+	clock.Advance(2 * time.Minute)
+	debug("d")
+	if ids := fmt.Sprint(requests()); ids != "[d]" {
+		t.Errorf("buffered requests %s, want the idle ones discarded", ids)
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestVerbosityInteraction(t *testing.T) {
+	self := selfPackage
+	tests := []struct {
+		name        string
+		verbosity   int
+		modules     map[string]int
+		level       LogLevel
+		moduleLevel *LogLevel
+		v           int
+		want        bool
+	}{
+		{name: "default enables V(0)", level: DEBUG, v: 0, want: true},
+		{name: "default disables V(1)", level: DEBUG, v: 1, want: false},
+		{name: "global verbosity", verbosity: 2, level: DEBUG, v: 2, want: true},
+		{name: "above global verbosity", verbosity: 2, level: DEBUG, v: 3, want: false},
+		{name: "module raises verbosity", modules: map[string]int{self: 3}, level: DEBUG, v: 3, want: true},
+		{name: "module lowers verbosity", verbosity: 5, modules: map[string]int{self: 0}, level: DEBUG, v: 1, want: false},
+		{name: "other module ignored", verbosity: 1, modules: map[string]int{"example.com/other": 5}, level: DEBUG, v: 2, want: false},
+		{name: "longest prefix wins", modules: map[string]int{"github.com/73ddy-io": 0, self: 4}, level: DEBUG, v: 4, want: true},
+		{name: "level threshold filters DEBUG", verbosity: 5, level: INFO, v: 0, want: false},
+		{name: "module level lets DEBUG through", verbosity: 2, level: INFO, moduleLevel: levelPtr(DEBUG), v: 2, want: true},
+		{name: "module level filters DEBUG", verbosity: 2, level: DEBUG, moduleLevel: levelPtr(WARN), v: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := initTestLogger(t, "app.log")
+			SetVerbosity(tt.verbosity)
+			for prefix, v := range tt.modules {
+				SetModuleVerbosity(prefix, v)
+			}
+			SetLevel(tt.level)
+			if tt.moduleLevel != nil {
+				SetModuleLevel(self, *tt.moduleLevel)
+			}
+			t.Cleanup(func() {
+				SetVerbosity(0)
+				for prefix := range tt.modules {
+					ClearModuleVerbosity(prefix)
+				}
+				ClearModuleLevel(self)
+			})
+
+			V(tt.v).Info("verbose")
+			Error("marker")
+
+			got := messages(t, filepath.Join(dir, "app.log"))
+			logged := len(got) == 2 && got[0] == "verbose v="+strconv.Itoa(tt.v)
+			if logged != tt.want {
+				t.Errorf("V(%d) logged %q, want logged = %v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func levelPtr(l LogLevel) *LogLevel { return &l }